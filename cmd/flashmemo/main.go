@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"memoflash/internal/db"
 	"memoflash/internal/services"
 	"memoflash/internal/ui"
+	"memoflash/pkg/clock"
 	"path"
 
 	"cogentcore.org/core/core"
@@ -32,13 +34,20 @@ func initialization() (*services.Service, error) {
 		return nil, err
 	}
 
-	if err := db.InitSchema(); err != nil {
+	if err := db.InitSchema(context.Background()); err != nil {
 		return nil, err
 	}
 
+	calendar := clock.NewCalendar(clock.System, ui.Settings.DayStartHour)
 	service := &services.Service{
-		CardService: services.NewCardService(db),
-		DeckService: services.NewDeckService(db),
+		Calendar:        calendar,
+		CardService:     services.NewCardService(db, calendar),
+		DeckService:     services.NewDeckService(db, calendar),
+		NoteService:     services.NewNoteService(db, calendar),
+		PresetService:   services.NewPresetService(db),
+		SessionService:  services.NewSessionService(db, calendar),
+		ActivityService: services.NewActivityService(db, calendar),
+		GoalService:     services.NewGoalService(db, calendar),
 	}
 	if err != nil {
 		return nil, err
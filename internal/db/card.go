@@ -1,10 +1,12 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -13,18 +15,27 @@ import (
 type CardFilter struct {
 	Where any
 	Order string
+	Limit uint64
 }
 
-func (database *Database) GetCards(filter CardFilter) ([]*models.Card, error) {
+// cardColumns lists the cards columns in the order GetCards scans them.
+var cardColumns = []string{
+	"ID", "Front", "Back", "Stability", "Difficulty", "LastStudied", "ParentDeckId", "Interval", "NoteId", "Ord", "BuriedUntil", "Suspended", "Flag", "Lapses", "Tags", "TypeAnswer", "Step",
+}
+
+func (database *Database) GetCards(ctx context.Context, filter CardFilter) ([]*models.Card, error) {
 	var cards []*models.Card
-	queryBuilder := sq.Select("*").From("cards").RunWith(database.db)
+	queryBuilder := sq.Select(cardColumns...).From("cards").RunWith(database.db)
 	if filter.Where != nil {
 		queryBuilder = queryBuilder.Where(filter.Where)
 	}
 	if filter.Order != "" {
 		queryBuilder = queryBuilder.OrderBy(filter.Order)
 	}
-	rows, err := queryBuilder.Query()
+	if filter.Limit > 0 {
+		queryBuilder = queryBuilder.Limit(filter.Limit)
+	}
+	rows, err := queryBuilder.QueryContext(ctx)
 	if err != nil {
 		return cards, err
 	}
@@ -32,11 +43,15 @@ func (database *Database) GetCards(filter CardFilter) ([]*models.Card, error) {
 	for rows.Next() {
 		var interval sql.NullInt64
 		var lastStudied sql.NullInt64
+		var noteId sql.NullInt64
+		var buriedUntil sql.NullInt64
+		var lapses sql.NullInt64
+		var tags sql.NullString
+		var step sql.NullInt64
 		card := new(models.Card)
-		err = rows.Scan(&card.ID, &card.Front, &card.Back, &card.Stability, &card.Difficulty, &lastStudied, &card.ParentDeckId, &interval)
+		err = rows.Scan(&card.ID, &card.Front, &card.Back, &card.Stability, &card.Difficulty, &lastStudied, &card.ParentDeckId, &interval, &noteId, &card.Ord, &buriedUntil, &card.Suspended, &card.Flag, &lapses, &tags, &card.TypeAnswer, &step)
 		if err != nil {
-			log.Println("Error Scanning Row :", err)
-			continue
+			return nil, fmt.Errorf("scan card: %w", err)
 		}
 		if lastStudied.Valid && lastStudied.Int64 != 0 {
 			validLastStudied := time.Unix(lastStudied.Int64, 0)
@@ -46,68 +61,175 @@ func (database *Database) GetCards(filter CardFilter) ([]*models.Card, error) {
 			validInterval := time.Unix(interval.Int64, 0)
 			card.Interval = validInterval
 		}
+		card.NoteID = int(noteId.Int64)
+		if buriedUntil.Valid && buriedUntil.Int64 != 0 {
+			card.BuriedUntil = time.Unix(buriedUntil.Int64, 0)
+		}
+		card.Lapses = int(lapses.Int64)
+		card.Step = int(step.Int64)
+		card.Tags = strings.Fields(tags.String)
 		cards = append(cards, card)
-
 	}
-	return cards, nil
+	return cards, rows.Err()
 }
-func (database *Database) CreateCard(front, back string, parentDeckId int) error {
+func (database *Database) CreateCard(ctx context.Context, front, back string, parentDeckId int) error {
 	card, err := sq.Insert("cards").Columns("Front", "Back", "ParentDeckId").
 		Values(front, back, parentDeckId).
 		RunWith(database.db).
-		Exec()
+		ExecContext(ctx)
 	if err != nil {
-		return fmt.Errorf("Error Executing Statement: %w", err)
+		return writeError("card", 0, err)
 	}
-	rowsAffected, err := card.RowsAffected()
+	return requireRows(card, "card", 0)
+}
+
+// AddCard inserts card and returns its ID. Cards without a note are stored
+// with a NULL NoteId.
+func (database *Database) AddCard(ctx context.Context, card *models.Card) (int, error) {
+	var noteId any
+	if card.NoteID != 0 {
+		noteId = card.NoteID
+	}
+	result, err := sq.Insert("cards").Columns("Front", "Back", "ParentDeckId", "NoteId", "Ord").
+		Values(card.Front, card.Back, card.ParentDeckId, noteId, card.Ord).
+		RunWith(database.db).
+		ExecContext(ctx)
 	if err != nil {
-		log.Println("Error Retrieving Rows Affected:", err)
+		return 0, writeError("card", 0, err)
 	}
-	if rowsAffected == 0 {
-		log.Println("No rows affected")
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return int(id), nil
 }
 
-func (database *Database) EditCard(front, back string, id int) error {
-
-	_, err := sq.Update("cards").Set("Front", front).Set("Back", back).Where("id = ?", id).RunWith(database.db).Exec()
-
+func (database *Database) EditCard(ctx context.Context, front, back string, id int) error {
+	result, err := sq.Update("cards").Set("Front", front).Set("Back", back).Where("id = ?", id).RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		return err
+		return writeError("card", id, err)
 	}
-	return nil
+	return requireRows(result, "card", id)
 }
 
-func (database *Database) DeleteCard(cardfilter CardFilter) error {
+func (database *Database) DeleteCard(ctx context.Context, cardfilter CardFilter) error {
 	query := sq.Delete("cards")
 	if cardfilter.Where != nil {
 		query = query.Where(cardfilter.Where)
 	}
-	result, err := query.RunWith(database.db).Exec()
+	result, err := query.RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		return fmt.Errorf("Error Executing Statement: %w", err)
+		return writeError("card", 0, err)
 	}
-	rowsAffected, err := result.RowsAffected()
+	return requireRows(result, "card", 0)
+}
+
+// UpdateInterval stores the scheduling state of card after a review.
+func (database *Database) UpdateInterval(ctx context.Context, card *models.Card) error {
+	result, err := sq.Update("cards").
+		Set("Interval", card.Interval.Unix()).
+		Set("Stability", card.Stability).
+		Set("Difficulty", card.Difficulty).
+		Set("LastStudied", card.LastStudied.Unix()).
+		Set("Lapses", card.Lapses).
+		Set("Step", card.Step).
+		Where(sq.Eq{"ID": card.ID}).
+		RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		log.Println("Error Retrieving Rows Affected:", err)
+		return writeError("card", card.ID, err)
+	}
+	return requireRows(result, "card", card.ID)
+}
+
+// RestoreSchedule writes back the scheduling state of card as it was before
+// a review, including its lapses, tags and suspension. Zero times are
+// stored as NULL so a card that was new is new again.
+func (database *Database) RestoreSchedule(ctx context.Context, card *models.Card) error {
+	var interval, lastStudied any
+	if !card.Interval.IsZero() {
+		interval = card.Interval.Unix()
 	}
-	if rowsAffected == 0 {
-		log.Println("No rows affected")
+	if !card.LastStudied.IsZero() {
+		lastStudied = card.LastStudied.Unix()
 	}
+	result, err := sq.Update("cards").
+		Set("Interval", interval).
+		Set("Stability", card.Stability).
+		Set("Difficulty", card.Difficulty).
+		Set("LastStudied", lastStudied).
+		Set("Lapses", card.Lapses).
+		Set("Tags", strings.Join(card.Tags, " ")).
+		Set("Suspended", card.Suspended).
+		Set("Step", card.Step).
+		Where(sq.Eq{"ID": card.ID}).
+		RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("card", card.ID, err)
+	}
+	return requireRows(result, "card", card.ID)
+}
 
-	return nil
+// BuryCards hides the cards from every queue until the given time. A zero
+// time unburies them.
+func (database *Database) BuryCards(ctx context.Context, ids []int, until time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var buriedUntil any
+	if !until.IsZero() {
+		buriedUntil = until.Unix()
+	}
+	result, err := sq.Update("cards").Set("BuriedUntil", buriedUntil).Where(sq.Eq{"ID": ids}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("card", recordID(ids), err)
+	}
+	return requireRows(result, "card", recordID(ids))
 }
 
-func (database *Database) UpdateInterval(interval time.Time, Stability float64, Difficulty float64, id int) error {
-	timeInterval := interval.Unix()
-	s, err := database.db.Prepare("UPDATE cards SET interval = ?, stability = ?, difficulty = ? WHERE id = ?")
+func (database *Database) SetSuspended(ctx context.Context, ids []int, suspended bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	result, err := sq.Update("cards").Set("Suspended", suspended).Where(sq.Eq{"ID": ids}).RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		return err
+		return writeError("card", recordID(ids), err)
 	}
-	_, err = s.Exec(timeInterval, Stability, Difficulty, id)
+	return requireRows(result, "card", recordID(ids))
+}
+
+func (database *Database) SetFlag(ctx context.Context, id int, flag values.Flag) error {
+	result, err := sq.Update("cards").Set("Flag", flag).Where(sq.Eq{"ID": id}).RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		return fmt.Errorf("Error Executing Query: %w", err)
+		return writeError("card", id, err)
+	}
+	return requireRows(result, "card", id)
+}
+
+// SetTags replaces the tags of the card. Tags are stored space separated.
+func (database *Database) SetTags(ctx context.Context, id int, tags []string) error {
+	result, err := sq.Update("cards").Set("Tags", strings.Join(tags, " ")).Where(sq.Eq{"ID": id}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("card", id, err)
+	}
+	return requireRows(result, "card", id)
+}
+
+func (database *Database) SetTypeAnswer(ctx context.Context, ids []int, typeAnswer bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	result, err := sq.Update("cards").Set("TypeAnswer", typeAnswer).Where(sq.Eq{"ID": ids}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("card", recordID(ids), err)
+	}
+	return requireRows(result, "card", recordID(ids))
+}
+
+// recordID returns the ID to report in errors about the cards with ids: the
+// ID itself for a single card, zero otherwise.
+func recordID(ids []int) int {
+	if len(ids) == 1 {
+		return ids[0]
 	}
-	return nil
+	return 0
 }
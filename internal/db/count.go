@@ -0,0 +1,25 @@
+package db
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type CounterFilter struct {
+	Condition any
+	Table     string
+}
+
+func (database *Database) Count(ctx context.Context, filter CounterFilter) (float32, error) {
+	queryBuilder := sq.Select("COUNT(*)").From(filter.Table)
+	if filter.Condition != nil {
+		queryBuilder = queryBuilder.Where(filter.Condition)
+	}
+	var count float32
+	err := queryBuilder.RunWith(database.db).QueryRowContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
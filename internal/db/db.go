@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	_ "github.com/mattn/go-sqlite3"
@@ -20,9 +22,22 @@ const (
 	NONE                   OrderBy = "NONE"
 )
 
+// runner executes statements, either on the connection pool or inside a
+// transaction.
+type runner interface {
+	sq.StdSqlCtx
+}
+
 type Database struct {
-	db   *sql.DB
-	psql sq.StatementBuilderType
+	// db runs every statement: conn, or tx while inside WithTx.
+	db   runner
+	conn *sql.DB
+	tx   *sql.Tx
+}
+
+// Tx is a Database whose methods all run inside one transaction.
+type Tx struct {
+	*Database
 }
 
 func SetupDatabase(name string) (*Database, error) {
@@ -30,23 +45,133 @@ func SetupDatabase(name string) (*Database, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	return &Database{db: db, psql: sq.StatementBuilder.RunWith(db)}, nil
+	return &Database{db: db, conn: db}, nil
 }
 
-func (database *Database) InitSchema() error {
+// SetupMemoryDatabase opens an empty database held in memory, as used by
+// tests. Every connection to an in-memory database opens a database of its
+// own, so the pool is limited to a single connection.
+func SetupMemoryDatabase() (*Database, error) {
+	database, err := SetupDatabase("file::memory:")
+	if err != nil {
+		return nil, err
+	}
+	database.conn.SetMaxOpenConns(1)
+	return database, nil
+}
+
+// WithTx runs fn inside a transaction, committing it when fn returns nil and
+// rolling it back when fn fails or panics. Calling WithTx on a Tx runs fn in
+// the enclosing transaction, which then commits or rolls back as a whole.
+func (database *Database) WithTx(ctx context.Context, fn func(tx *Tx) error) (err error) {
+	if database.tx != nil {
+		return fn(&Tx{Database: database})
+	}
+	sqlTx, err := database.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			sqlTx.Rollback()
+		}
+	}()
+	if err = fn(&Tx{Database: &Database{db: sqlTx, conn: database.conn, tx: sqlTx}}); err != nil {
+		return err
+	}
+	if err = sqlTx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (database *Database) InitSchema(ctx context.Context) error {
 
 	tableQueries := []string{
-		`CREATE TABLE IF NOT EXISTS states (
-			dayStreak       INTEGER DEFAULT 0,
-			lastTimeUpdated INTEGER DEFAULT 0
-		)`,
 		`CREATE TABLE IF NOT EXISTS decks (
 			ID                 INTEGER PRIMARY KEY AUTOINCREMENT,
 			Title              TEXT,
 			Description        TEXT,
 			LastStudied        INTEGER,
 			CategoryColorIndex TINYINT DEFAULT 0,
-			CreatedAt          INTEGER DEFAULT (strftime('%s','now'))
+			CreatedAt          INTEGER DEFAULT (strftime('%s','now')),
+			PresetId           INTEGER REFERENCES presets(ID) ON DELETE SET NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS presets (
+			ID               INTEGER PRIMARY KEY AUTOINCREMENT,
+			Name             TEXT UNIQUE,
+			DesiredRetention REAL DEFAULT 0.9,
+			MaximumInterval  INTEGER DEFAULT 36500,
+			NewPerDay        INTEGER DEFAULT 20,
+			ReviewsPerDay    INTEGER DEFAULT 200,
+			LearningSteps    TEXT DEFAULT '1m 10m',
+			LeechThreshold   INTEGER DEFAULT 8,
+			LeechAction      INTEGER DEFAULT 0,
+			BuryNew          INTEGER DEFAULT 1,
+			BuryReview       INTEGER DEFAULT 1,
+			Weights          TEXT DEFAULT '[]'
+		)`,
+		`CREATE TABLE IF NOT EXISTS reviews (
+			ID         INTEGER PRIMARY KEY AUTOINCREMENT,
+			CardId     INTEGER,
+			DeckId     INTEGER,
+			Rating     INTEGER,
+			WasNew     INTEGER DEFAULT 0,
+			ReviewedAt INTEGER DEFAULT (strftime('%s','now')),
+			Duration   INTEGER DEFAULT 0,
+			FOREIGN KEY (CardId) REFERENCES cards(ID) ON DELETE CASCADE,
+			FOREIGN KEY (DeckId) REFERENCES decks(ID) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS goals (
+			ID       INTEGER PRIMARY KEY AUTOINCREMENT,
+			Kind     INTEGER DEFAULT 0,
+			Target   INTEGER DEFAULT 0,
+			StartsAt INTEGER
+		)`,
+		`CREATE TABLE IF NOT EXISTS study_sessions (
+			ID        INTEGER PRIMARY KEY AUTOINCREMENT,
+			DeckId    INTEGER REFERENCES decks(ID) ON DELETE SET NULL,
+			Mode      INTEGER DEFAULT 0,
+			StartedAt INTEGER,
+			EndedAt   INTEGER,
+			Cards     INTEGER DEFAULT 0,
+			Answers   INTEGER DEFAULT 0,
+			Again     INTEGER DEFAULT 0,
+			Hard      INTEGER DEFAULT 0,
+			Good      INTEGER DEFAULT 0,
+			Easy      INTEGER DEFAULT 0,
+			Reviewed  INTEGER DEFAULT 0,
+			Lapses    INTEGER DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS note_types (
+			ID     INTEGER PRIMARY KEY AUTOINCREMENT,
+			Name   TEXT UNIQUE,
+			Kind   INTEGER DEFAULT 0,
+			Fields TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS templates (
+			ID         INTEGER PRIMARY KEY AUTOINCREMENT,
+			NoteTypeId INTEGER,
+			Ord        INTEGER,
+			Name       TEXT,
+			Front      TEXT,
+			Back       TEXT,
+			UNIQUE (NoteTypeId, Ord),
+			FOREIGN KEY (NoteTypeId) REFERENCES note_types(ID) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS notes (
+			ID         INTEGER PRIMARY KEY AUTOINCREMENT,
+			NoteTypeId INTEGER,
+			DeckId     INTEGER,
+			Fields     TEXT,
+			CreatedAt  INTEGER DEFAULT (strftime('%s','now')),
+			DeletedOrds TEXT DEFAULT '[]',
+			FOREIGN KEY (NoteTypeId) REFERENCES note_types(ID),
+			FOREIGN KEY (DeckId) REFERENCES decks(ID) ON DELETE CASCADE
 		)`,
 		`CREATE TABLE IF NOT EXISTS cards (
 			ID           INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -57,31 +182,92 @@ func (database *Database) InitSchema() error {
 			LastStudied  INTEGER,
 			ParentDeckId INTEGER,
 			Interval     INTEGER,
+			NoteId       INTEGER REFERENCES notes(ID) ON DELETE CASCADE,
+			Ord          INTEGER DEFAULT 0,
+			BuriedUntil  INTEGER,
+			Suspended    INTEGER DEFAULT 0,
+			Flag         INTEGER DEFAULT 0,
+			Lapses       INTEGER DEFAULT 0,
+			Tags         TEXT DEFAULT '',
+			TypeAnswer   INTEGER DEFAULT 0,
+			Step         INTEGER DEFAULT 0,
 			FOREIGN KEY (ParentDeckId) REFERENCES decks(ID) ON DELETE CASCADE
 		)`,
 	}
 	for _, query := range tableQueries {
-		if _, err := database.db.Exec(query); err != nil {
+		if _, err := database.db.ExecContext(ctx, query); err != nil {
 			return fmt.Errorf("create table: %w", err)
 		}
 	}
-	sqlStmt, args, _ := sq.Select("dayStreak").From("states").Limit(1).ToSql()
+	// Columns added after the first release; databases created before them
+	// only get the columns through these migrations.
+	columnMigrations := []struct {
+		table, column, definition string
+	}{
+		{"cards", "NoteId", "INTEGER REFERENCES notes(ID) ON DELETE CASCADE"},
+		{"cards", "Ord", "INTEGER DEFAULT 0"},
+		{"note_types", "Kind", "INTEGER DEFAULT 0"},
+		{"decks", "PresetId", "INTEGER REFERENCES presets(ID) ON DELETE SET NULL"},
+		{"cards", "BuriedUntil", "INTEGER"},
+		{"cards", "Suspended", "INTEGER DEFAULT 0"},
+		{"cards", "Flag", "INTEGER DEFAULT 0"},
+		{"cards", "Lapses", "INTEGER DEFAULT 0"},
+		{"cards", "Tags", "TEXT DEFAULT ''"},
+		{"cards", "TypeAnswer", "INTEGER DEFAULT 0"},
+		{"reviews", "Duration", "INTEGER DEFAULT 0"},
+		{"notes", "DeletedOrds", "TEXT DEFAULT '[]'"},
+		{"cards", "Step", "INTEGER DEFAULT 0"},
+	}
+	for _, migration := range columnMigrations {
+		if err := database.ensureColumn(ctx, migration.table, migration.column, migration.definition); err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", migration.table, migration.column, err)
+		}
+	}
+	if err := database.seedNoteTypes(ctx); err != nil {
+		return fmt.Errorf("seed note types: %w", err)
+	}
+	if err := database.seedPresets(ctx); err != nil {
+		return fmt.Errorf("seed presets: %w", err)
+	}
+	if err := database.migrateDeckOptions(ctx); err != nil {
+		return fmt.Errorf("migrate deck options: %w", err)
+	}
+	return nil
+}
 
-	var dayStreak int
-	err := database.db.QueryRow(sqlStmt, args...).Scan(&dayStreak)
+// ensureColumn adds column to table unless it already exists.
+func (database *Database) ensureColumn(ctx context.Context, table, column, definition string) error {
+	found, err := database.hasColumn(ctx, table, column)
+	if err != nil || found {
+		return err
+	}
+	_, err = database.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
 
-	if err == sql.ErrNoRows {
-		_, err = sq.Insert("states").
-			Columns("dayStreak", "lastTimeUpdated").
-			Values(0, 0).
-			RunWith(database.db).
-			Exec()
-		if err != nil {
-			return fmt.Errorf("seed initial state: %w", err)
+// hasColumn reports whether table has column.
+func (database *Database) hasColumn(ctx context.Context, table, column string) (bool, error) {
+	rows, err := database.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid          int
+			name, typ    string
+			notNull, pk  int
+			defaultValue sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if strings.EqualFold(name, column) {
+			return true, nil
 		}
 	}
-	return nil
+	return false, rows.Err()
 }
 func (database *Database) Close() {
-	database.db.Close()
+	database.conn.Close()
 }
@@ -0,0 +1,330 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var ctx = context.Background()
+
+func openDatabase(t *testing.T) *db.Database {
+	t.Helper()
+	database, err := db.SetupDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(database.Close)
+	if err := database.InitSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+func count(t *testing.T, database *db.Database, table string, condition any) int {
+	t.Helper()
+	n, err := database.Count(ctx, db.CounterFilter{Table: table, Condition: condition})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(n)
+}
+
+func TestWithTxCommits(t *testing.T) {
+	database := openDatabase(t)
+	err := database.WithTx(ctx, func(tx *db.Tx) error {
+		deckId, err := tx.CreateDeck(ctx, "Deck", "", 0, time.Now())
+		if err != nil {
+			return err
+		}
+		_, err = tx.AddCard(ctx, &models.Card{Front: "front", Back: "back", ParentDeckId: deckId})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if got := count(t, database, "decks", nil); got != 1 {
+		t.Errorf("decks = %d, want 1", got)
+	}
+	if got := count(t, database, "cards", nil); got != 1 {
+		t.Errorf("cards = %d, want 1", got)
+	}
+}
+
+func TestWithTxRollsBack(t *testing.T) {
+	errInjected := errors.New("injected failure")
+
+	tests := []struct {
+		name    string
+		fn      func(tx *db.Tx, deckId int) error
+		wantErr error
+	}{
+		{
+			name: "error returned",
+			fn: func(tx *db.Tx, deckId int) error {
+				return errInjected
+			},
+			wantErr: errInjected,
+		},
+		{
+			name: "failing statement",
+			fn: func(tx *db.Tx, deckId int) error {
+				// The card does not exist, so the foreign key rejects the review.
+				return tx.AddReview(ctx, &models.Review{CardID: 404, DeckID: deckId, ReviewedAt: time.Now()})
+			},
+		},
+		{
+			name: "nested transaction fails",
+			fn: func(tx *db.Tx, deckId int) error {
+				return tx.WithTx(ctx, func(inner *db.Tx) error {
+					if _, err := inner.AddCard(ctx, &models.Card{Front: "inner", ParentDeckId: deckId}); err != nil {
+						return err
+					}
+					return errInjected
+				})
+			},
+			wantErr: errInjected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := openDatabase(t)
+			err := database.WithTx(ctx, func(tx *db.Tx) error {
+				deckId, err := tx.CreateDeck(ctx, "Deck", "", 0, time.Now())
+				if err != nil {
+					return err
+				}
+				if _, err := tx.AddCard(ctx, &models.Card{Front: "front", Back: "back", ParentDeckId: deckId}); err != nil {
+					return err
+				}
+				return tt.fn(tx, deckId)
+			})
+			if err == nil {
+				t.Fatal("WithTx() error = nil, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("WithTx() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := count(t, database, "decks", nil); got != 0 {
+				t.Errorf("decks = %d after rollback, want 0", got)
+			}
+			if got := count(t, database, "cards", nil); got != 0 {
+				t.Errorf("cards = %d after rollback, want 0", got)
+			}
+		})
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	database := openDatabase(t)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithTx() did not re-panic")
+			}
+		}()
+		database.WithTx(ctx, func(tx *db.Tx) error {
+			if _, err := tx.CreateDeck(ctx, "Deck", "", 0, time.Now()); err != nil {
+				return err
+			}
+			panic("injected panic")
+		})
+	}()
+	if got := count(t, database, "decks", nil); got != 0 {
+		t.Errorf("decks = %d after panic, want 0", got)
+	}
+	// The connection must be usable again once the transaction is gone.
+	if _, err := database.CreateDeck(ctx, "Deck", "", 0, time.Now()); err != nil {
+		t.Errorf("CreateDeck() after panic error = %v", err)
+	}
+}
+
+func TestCreateNoteTypeRollsBack(t *testing.T) {
+	database := openDatabase(t)
+	noteType := &models.NoteType{
+		Name:   "Broken",
+		Fields: []string{"Front", "Back"},
+		Templates: []*models.Template{
+			{Ord: 0, Name: "Broken 1", Front: "{{Front}}", Back: "{{Back}}"},
+			// A second template with the same Ord violates the unique index.
+			{Ord: 0, Name: "Broken 2", Front: "{{Back}}", Back: "{{Front}}"},
+		},
+	}
+	if _, err := database.CreateNoteType(ctx, noteType); err == nil {
+		t.Fatal("CreateNoteType() error = nil, want an error")
+	}
+	if got := count(t, database, "note_types", sq.Eq{"Name": "Broken"}); got != 0 {
+		t.Errorf("note types = %d after rollback, want 0", got)
+	}
+	if got := count(t, database, "templates", sq.Eq{"Name": "Broken 1"}); got != 0 {
+		t.Errorf("templates = %d after rollback, want 0", got)
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	database := openDatabase(t)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := database.GetCards(canceled, db.CardFilter{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetCards() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := database.CreateDeck(canceled, "Deck", "", 0, time.Now()); !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateDeck() error = %v, want %v", err, context.Canceled)
+	}
+	if got := count(t, database, "decks", nil); got != 0 {
+		t.Errorf("decks = %d, want 0", got)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        func(database *db.Database) error
+		want       error
+		wantFields []string
+	}{
+		{
+			name: "delete missing card",
+			run: func(database *db.Database) error {
+				return database.DeleteCard(ctx, db.CardFilter{Where: sq.Eq{"ID": 404}})
+			},
+			want: db.ErrNotFound,
+		},
+		{
+			name: "edit missing card",
+			run: func(database *db.Database) error {
+				return database.EditCard(ctx, "front", "back", 404)
+			},
+			want: db.ErrNotFound,
+		},
+		{
+			name: "edit missing deck",
+			run: func(database *db.Database) error {
+				return database.EditDeck(ctx, 404, "Deck", "", 0)
+			},
+			want: db.ErrNotFound,
+		},
+		{
+			name: "delete missing deck",
+			run: func(database *db.Database) error {
+				return database.DeleteDeck(ctx, db.DeckFilter{Where: sq.Eq{"ID": 404}})
+			},
+			want: db.ErrNotFound,
+		},
+		{
+			name: "missing note type",
+			run: func(database *db.Database) error {
+				_, err := database.GetNoteType(ctx, 404)
+				return err
+			},
+			want: db.ErrNotFound,
+		},
+		{
+			name: "duplicate preset name",
+			run: func(database *db.Database) error {
+				_, err := database.CreatePreset(ctx, db.DefaultPreset())
+				return err
+			},
+			want:       db.ErrConflict,
+			wantFields: []string{"Name"},
+		},
+		{
+			name: "card in missing deck",
+			run: func(database *db.Database) error {
+				_, err := database.AddCard(ctx, &models.Card{Front: "front", ParentDeckId: 404})
+				return err
+			},
+			want: db.ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(openDatabase(t))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			var recordErr *db.RecordError
+			if !errors.As(err, &recordErr) {
+				t.Fatalf("error = %T, want *db.RecordError", err)
+			}
+			if !slices.Equal(recordErr.Fields, tt.wantFields) {
+				t.Errorf("Fields = %q, want %q", recordErr.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestMigrateDeckOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.SetupDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.InitSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	database.Close()
+
+	// Recreate the columns decks held before presets and fill them in the
+	// way an older version would have.
+	legacy, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{
+		"ALTER TABLE decks ADD COLUMN BuryNew INTEGER DEFAULT 1",
+		"ALTER TABLE decks ADD COLUMN BuryReview INTEGER DEFAULT 1",
+		"ALTER TABLE decks ADD COLUMN LeechThreshold INTEGER DEFAULT 8",
+		"ALTER TABLE decks ADD COLUMN LeechAction INTEGER DEFAULT 0",
+		"INSERT INTO decks (Title, Description) VALUES ('Plain', '')",
+		"INSERT INTO decks (Title, Description, BuryNew, LeechThreshold, LeechAction) VALUES ('Custom', '', 0, 4, 1)",
+	} {
+		if _, err := legacy.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	legacy.Close()
+
+	database, err = db.SetupDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.InitSchema(ctx); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+
+	decks, err := database.GetDecks(ctx, db.DeckFilter{OrderBy: "decks.ID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decks) != 2 {
+		t.Fatalf("decks = %d, want 2", len(decks))
+	}
+	if decks[0].PresetID != 0 {
+		t.Errorf("plain deck preset = %d, want the default preset", decks[0].PresetID)
+	}
+	preset, err := database.GetPreset(ctx, decks[1].PresetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preset.Name != "Custom options" || preset.BuryNew || !preset.BuryReview ||
+		preset.LeechThreshold != 4 || preset.LeechAction != values.SuspendLeech {
+		t.Errorf("custom deck preset = %+v, want its own options", preset)
+	}
+
+	for _, column := range []string{"BuryNew", "BuryReview", "LeechThreshold", "LeechAction"} {
+		if _, err := database.GetDecks(ctx, db.DeckFilter{Where: sq.Expr("decks." + column + " IS NULL")}); err == nil {
+			t.Errorf("decks.%s still exists", column)
+		}
+	}
+}
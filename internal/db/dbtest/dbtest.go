@@ -0,0 +1,212 @@
+// Package dbtest provides fresh in-memory databases and builders of decks,
+// cards and reviews for tests.
+package dbtest
+
+import (
+	"context"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// New returns an empty in-memory database with the schema applied. It is
+// closed when the test ends.
+func New(t testing.TB) *db.Database {
+	t.Helper()
+	database, err := db.SetupMemoryDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(database.Close)
+	if err := database.InitSchema(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+// Count returns the number of rows of table matching condition, or every
+// row when condition is nil.
+func Count(t testing.TB, database *db.Database, table string, condition any) int {
+	t.Helper()
+	n, err := database.Count(context.Background(), db.CounterFilter{Table: table, Condition: condition})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(n)
+}
+
+// GetCard reads the card with id back from the database.
+func GetCard(t testing.TB, database *db.Database, id int) *models.Card {
+	t.Helper()
+	cards, err := database.GetCards(context.Background(), db.CardFilter{Where: sq.Eq{"ID": id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) == 0 {
+		t.Fatalf("card %d not found", id)
+	}
+	return cards[0]
+}
+
+// DeckBuilder describes a deck to add with Add.
+type DeckBuilder struct {
+	deck models.Deck
+}
+
+// Deck starts a deck titled title.
+func Deck(title string) *DeckBuilder {
+	return &DeckBuilder{deck: models.Deck{Title: title}}
+}
+
+func (b *DeckBuilder) Description(description string) *DeckBuilder {
+	b.deck.Description = description
+	return b
+}
+
+// StudiedAt sets when the deck was last studied.
+func (b *DeckBuilder) StudiedAt(at time.Time) *DeckBuilder {
+	b.deck.LastStudied = at
+	return b
+}
+
+// Preset schedules the deck with the preset with id.
+func (b *DeckBuilder) Preset(id int) *DeckBuilder {
+	b.deck.PresetID = id
+	return b
+}
+
+// Add stores the deck and returns it with its ID set.
+func (b *DeckBuilder) Add(t testing.TB, database *db.Database) *models.Deck {
+	t.Helper()
+	ctx := context.Background()
+	deck := b.deck
+	id, err := database.CreateDeck(ctx, deck.Title, deck.Description, deck.CategoryIndex, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deck.ID = id
+	if !deck.LastStudied.IsZero() {
+		if err := database.UpdateReadTime(ctx, id, deck.LastStudied); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if deck.PresetID != 0 {
+		if err := database.SetDeckPreset(ctx, id, deck.PresetID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &deck
+}
+
+// CardBuilder describes a card to add with Add. Cards start out new, with
+// the scheduling defaults of the schema.
+type CardBuilder struct {
+	card models.Card
+}
+
+// Card starts a card of the deck with deckId.
+func Card(deckId int) *CardBuilder {
+	return &CardBuilder{card: models.Card{
+		Front:        "Front",
+		Back:         "Back",
+		ParentDeckId: deckId,
+		Stability:    1,
+		Difficulty:   0.3,
+	}}
+}
+
+func (b *CardBuilder) Sides(front, back string) *CardBuilder {
+	b.card.Front = front
+	b.card.Back = back
+	return b
+}
+
+// Due makes the card a reviewed card scheduled for at, last studied a day
+// before.
+func (b *CardBuilder) Due(at time.Time) *CardBuilder {
+	b.card.Interval = at
+	b.card.LastStudied = at.AddDate(0, 0, -1)
+	return b
+}
+
+func (b *CardBuilder) Lapses(lapses int) *CardBuilder {
+	b.card.Lapses = lapses
+	return b
+}
+
+func (b *CardBuilder) Tags(tags ...string) *CardBuilder {
+	b.card.Tags = tags
+	return b
+}
+
+func (b *CardBuilder) Suspended() *CardBuilder {
+	b.card.Suspended = true
+	return b
+}
+
+// BuriedUntil hides the card from the queues until at.
+func (b *CardBuilder) BuriedUntil(at time.Time) *CardBuilder {
+	b.card.BuriedUntil = at
+	return b
+}
+
+func (b *CardBuilder) Flag(flag values.Flag) *CardBuilder {
+	b.card.Flag = flag
+	return b
+}
+
+func (b *CardBuilder) TypeAnswer() *CardBuilder {
+	b.card.TypeAnswer = true
+	return b
+}
+
+// Add stores the card and returns it with its ID set.
+func (b *CardBuilder) Add(t testing.TB, database *db.Database) *models.Card {
+	t.Helper()
+	ctx := context.Background()
+	card := b.card
+	card.Tags = append([]string(nil), b.card.Tags...)
+	id, err := database.AddCard(ctx, &card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card.ID = id
+	if err := database.RestoreSchedule(ctx, &card); err != nil {
+		t.Fatal(err)
+	}
+	if !card.BuriedUntil.IsZero() {
+		if err := database.BuryCards(ctx, []int{id}, card.BuriedUntil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if card.Flag != values.NoFlag {
+		if err := database.SetFlag(ctx, id, card.Flag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if card.TypeAnswer {
+		if err := database.SetTypeAnswer(ctx, []int{id}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &card
+}
+
+// AddReview logs a review of card rated rating at the given time.
+func AddReview(t testing.TB, database *db.Database, card *models.Card, rating values.Difficulty, at time.Time) {
+	t.Helper()
+	err := database.AddReview(context.Background(), &models.Review{
+		CardID:     card.ID,
+		DeckID:     card.ParentDeckId,
+		Rating:     rating,
+		WasNew:     card.IsNew(),
+		ReviewedAt: at,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,9 +1,9 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"memoflash/internal/models"
 	"time"
 
@@ -16,7 +16,7 @@ type DeckFilter struct {
 	Where   any
 }
 
-func (database *Database) GetDecks(filter DeckFilter) ([]*models.Deck, error) {
+func (database *Database) GetDecks(ctx context.Context, filter DeckFilter) ([]*models.Deck, error) {
 	var decks []*models.Deck
 
 	SelectBuilder := sq.Select(
@@ -26,11 +26,11 @@ func (database *Database) GetDecks(filter DeckFilter) ([]*models.Deck, error) {
 		"decks.LastStudied",
 		"decks.CategoryColorIndex",
 		"decks.CreatedAt",
-		"COALESCE(COUNT(cards.ID), 0) as total_cards",
-		"COALESCE((SELECT COUNT(*) FROM cards WHERE cards.ParentDeckId = decks.ID AND (cards.interval IS NULL OR date(cards.interval,'unixepoch') <= date('now'))), 0) as due_cards").
+		"decks.PresetId",
+		"COALESCE(COUNT(cards.ID), 0) as total_cards").
 		From("decks").
 		LeftJoin("cards ON decks.ID = cards.ParentDeckId").
-		GroupBy("decks.ID", "decks.Title", "decks.Description", "decks.LastStudied", "decks.CategoryColorIndex", "decks.CreatedAt")
+		GroupBy("decks.ID", "decks.Title", "decks.Description", "decks.LastStudied", "decks.CategoryColorIndex", "decks.CreatedAt", "decks.PresetId")
 
 	if filter.Limit > 0 {
 		SelectBuilder = SelectBuilder.Limit(filter.Limit)
@@ -42,7 +42,7 @@ func (database *Database) GetDecks(filter DeckFilter) ([]*models.Deck, error) {
 		SelectBuilder = SelectBuilder.Where(filter.Where)
 	}
 
-	rows, err := SelectBuilder.RunWith(database.db).Query()
+	rows, err := SelectBuilder.RunWith(database.db).QueryContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,13 +53,12 @@ func (database *Database) GetDecks(filter DeckFilter) ([]*models.Deck, error) {
 		var LastStudiedinInt sql.NullInt64
 		var CreatedAt sql.NullInt64
 		var TotalCards int
-		var DueCards int
+		var PresetId sql.NullInt64
 
-		err = rows.Scan(&deck.ID, &deck.Title, &deck.Description, &LastStudiedinInt, &deck.CategoryIndex, &CreatedAt, &TotalCards, &DueCards)
+		err = rows.Scan(&deck.ID, &deck.Title, &deck.Description, &LastStudiedinInt, &deck.CategoryIndex, &CreatedAt, &PresetId, &TotalCards)
 
 		if err != nil {
-			log.Println("Error Scanning Row", err)
-			continue
+			return nil, fmt.Errorf("scan deck: %w", err)
 		}
 
 		if CreatedAt.Valid {
@@ -69,8 +68,8 @@ func (database *Database) GetDecks(filter DeckFilter) ([]*models.Deck, error) {
 			deck.LastStudied = time.Unix(LastStudiedinInt.Int64, 0)
 		}
 
+		deck.PresetID = int(PresetId.Int64)
 		deck.TotalCards = TotalCards
-		deck.DueCards = DueCards
 
 		decks = append(decks, deck)
 	}
@@ -81,13 +80,12 @@ func (database *Database) GetDecks(filter DeckFilter) ([]*models.Deck, error) {
 
 	return decks, nil
 }
-func (database *Database) CreateDeck(Title string, Description string, CategoryColorIndex int) (int, error) {
-	currentTime := time.Now().Unix()
+func (database *Database) CreateDeck(ctx context.Context, Title string, Description string, CategoryColorIndex int, createdAt time.Time) (int, error) {
 	result, err := sq.Insert("decks").Columns(
 		"Title", "Description", "CategoryColorIndex", "CreatedAt",
-	).Values(Title, Description, CategoryColorIndex, currentTime).RunWith(database.db).Exec()
+	).Values(Title, Description, CategoryColorIndex, createdAt.Unix()).RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		return 0, err
+		return 0, writeError("deck", 0, err)
 	}
 	lastInsertedId, err := result.LastInsertId()
 	if err != nil {
@@ -95,48 +93,47 @@ func (database *Database) CreateDeck(Title string, Description string, CategoryC
 	}
 	return int(lastInsertedId), nil
 }
-func (database *Database) EditDeck(id int, title, description string, categoryIndex int) error {
+func (database *Database) EditDeck(ctx context.Context, id int, title, description string, categoryIndex int) error {
 	result, err := sq.Update("decks").
 		Set("Title", title).Set("Description", description).Set("CategoryColorIndex", categoryIndex).
-		Where(sq.Eq{"id": id}).RunWith(database.db).Exec()
+		Where(sq.Eq{"id": id}).RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		return err
+		return writeError("deck", id, err)
 	}
-	affectedRows, err := result.RowsAffected()
-	if err != nil {
-		return err
+	return requireRows(result, "deck", id)
+}
+
+// SetDeckPreset assigns the option preset to the deck. Zero assigns the
+// default preset.
+func (database *Database) SetDeckPreset(ctx context.Context, id int, presetId int) error {
+	var preset any
+	if presetId != 0 {
+		preset = presetId
 	}
-	if affectedRows == 0 {
-		log.Println("No rows affected", id)
+	result, err := sq.Update("decks").Set("PresetId", preset).Where(sq.Eq{"ID": id}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("deck", id, err)
 	}
-
-	return nil
+	return requireRows(result, "deck", id)
 }
 
-func (database *Database) DeleteDeck(filter DeckFilter) error {
+func (database *Database) DeleteDeck(ctx context.Context, filter DeckFilter) error {
 	query := sq.Delete("decks").RunWith(database.db)
 	if filter.Where != nil {
 		query = query.Where(filter.Where)
 	}
-	result, err := query.Exec()
+	result, err := query.ExecContext(ctx)
 	if err != nil {
-		return fmt.Errorf("Error executing query: %w", err)
+		return writeError("deck", 0, err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAffected == 0 {
-		log.Println("No rows affected")
-	}
-	return nil
+	return requireRows(result, "deck", 0)
 }
-func (database *Database) UpdateReadTime(id int) error {
-	timeInterval := time.Now().Unix()
-	_, err := sq.Update("decks").Set("LastStudied", timeInterval).Where(sq.Eq{"id": id}).RunWith(database.db).Exec()
+
+// UpdateReadTime records at as the time the deck was last studied.
+func (database *Database) UpdateReadTime(ctx context.Context, id int, at time.Time) error {
+	result, err := sq.Update("decks").Set("LastStudied", at.Unix()).Where(sq.Eq{"id": id}).RunWith(database.db).ExecContext(ctx)
 	if err != nil {
-		return fmt.Errorf("Error preparing statement: %w", err)
+		return writeError("deck", id, err)
 	}
-	return nil
+	return requireRows(result, "deck", id)
 }
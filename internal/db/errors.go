@@ -0,0 +1,110 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Errors returned by the database methods, to be matched with errors.Is.
+// They usually come wrapped in a RecordError naming the record involved.
+var (
+	// ErrNotFound reports that no record matched.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict reports a write that clashes with an existing record, such
+	// as a duplicate name.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidInput reports values that can't be stored, such as a
+	// reference to a missing deck.
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// RecordError describes a failed operation on a record. errors.Is matches
+// it against its Kind, one of ErrNotFound, ErrConflict or ErrInvalidInput.
+type RecordError struct {
+	Kind error
+	// Entity names the kind of record, such as "card" or "deck".
+	Entity string
+	// ID is the record's ID, or zero when unknown.
+	ID int
+	// Fields names the columns whose values clashed, for an ErrConflict
+	// whose constraint is known.
+	Fields []string
+	// Err is the underlying cause, if any.
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	msg := e.Entity
+	if e.ID != 0 {
+		msg = fmt.Sprintf("%s %d", e.Entity, e.ID)
+	}
+	msg += ": " + e.Kind.Error()
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *RecordError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+func notFound(entity string, id int) error {
+	return &RecordError{Kind: ErrNotFound, Entity: entity, ID: id}
+}
+
+// writeError wraps an error returned by a statement writing entity.
+// Constraint failures become ErrConflict for unique values and
+// ErrInvalidInput otherwise.
+func writeError(entity string, id int, err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint {
+		recordErr := &RecordError{Kind: ErrInvalidInput, Entity: entity, ID: id, Err: err}
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			recordErr.Kind = ErrConflict
+			recordErr.Fields = constraintFields(sqliteErr.Error())
+		}
+		return recordErr
+	}
+	return fmt.Errorf("Error Executing Statement: %w", err)
+}
+
+// constraintFields returns the columns named by a SQLite constraint failure
+// such as "UNIQUE constraint failed: templates.NoteTypeId, templates.Ord".
+func constraintFields(msg string) []string {
+	_, columns, found := strings.Cut(msg, "constraint failed: ")
+	if !found {
+		return nil
+	}
+	var fields []string
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		if _, name, found := strings.Cut(column, "."); found {
+			column = name
+		}
+		fields = append(fields, column)
+	}
+	return fields
+}
+
+// requireRows returns ErrNotFound when the statement behind result changed
+// no rows.
+func requireRows(result sql.Result, entity string, id int) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound(entity, id)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"memoflash/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// AddGoal records goal as the daily goal from goal.StartsAt on.
+func (database *Database) AddGoal(ctx context.Context, goal *models.Goal) (int, error) {
+	result, err := sq.Insert("goals").Columns("Kind", "Target", "StartsAt").
+		Values(goal.Kind, goal.Target, goal.StartsAt.Unix()).
+		RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return 0, writeError("goal", 0, err)
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// GetGoals returns every goal ever set, in the order they took effect.
+func (database *Database) GetGoals(ctx context.Context) ([]*models.Goal, error) {
+	rows, err := sq.Select("ID", "Kind", "Target", "StartsAt").From("goals").
+		OrderBy("StartsAt", "ID").RunWith(database.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var goals []*models.Goal
+	for rows.Next() {
+		goal := new(models.Goal)
+		var startsAt int64
+		if err := rows.Scan(&goal.ID, &goal.Kind, &goal.Target, &startsAt); err != nil {
+			return nil, fmt.Errorf("scan goal: %w", err)
+		}
+		goal.StartsAt = time.Unix(startsAt, 0)
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"memoflash/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ReversedNoteType is the name of the default note type that produces a
+// front-to-back card and its back-to-front sibling.
+const ReversedNoteType = "Basic (and reversed card)"
+
+type NoteFilter struct {
+	Where any
+}
+
+// defaultNoteTypes are created by InitSchema when a type of the same name is missing.
+var defaultNoteTypes = []*models.NoteType{
+	{
+		Name:   "Basic",
+		Fields: []string{"Front", "Back"},
+		Templates: []*models.Template{
+			{Ord: 0, Name: "Card 1", Front: "{{Front}}", Back: "{{Back}}"},
+		},
+	},
+	{
+		Name:   ReversedNoteType,
+		Fields: []string{"Front", "Back"},
+		Templates: []*models.Template{
+			{Ord: 0, Name: "Card 1", Front: "{{Front}}", Back: "{{Back}}"},
+			{Ord: 1, Name: "Card 2", Front: "{{Back}}", Back: "{{Front}}"},
+		},
+	},
+	{
+		Name:   "Vocabulary",
+		Fields: []string{"Word", "Reading", "Meaning", "Example"},
+		Templates: []*models.Template{
+			{Ord: 0, Name: "Recognition", Front: "{{Word}}", Back: "{{Reading}}<br>{{Meaning}}<br>{{Example}}"},
+			{Ord: 1, Name: "Recall", Front: "{{Meaning}}", Back: "{{Word}}<br>{{Reading}}"},
+		},
+	},
+	{
+		Name:   "Cloze",
+		Kind:   models.ClozeNote,
+		Fields: []string{"Text", "Extra"},
+		Templates: []*models.Template{
+			{Ord: 0, Name: "Cloze", Front: "{{cloze:Text}}", Back: "{{cloze:Text}}<br>{{Extra}}"},
+		},
+	},
+}
+
+// seedNoteTypes creates the default note types missing from the database.
+func (database *Database) seedNoteTypes(ctx context.Context) error {
+	for _, noteType := range defaultNoteTypes {
+		var count int
+		err := sq.Select("COUNT(*)").From("note_types").Where(sq.Eq{"Name": noteType.Name}).
+			RunWith(database.db).QueryRowContext(ctx).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := database.CreateNoteType(ctx, noteType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateNoteType stores noteType together with its templates, all or
+// nothing.
+func (database *Database) CreateNoteType(ctx context.Context, noteType *models.NoteType) (int, error) {
+	fields, err := json.Marshal(noteType.Fields)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = database.WithTx(ctx, func(tx *Tx) error {
+		result, err := sq.Insert("note_types").Columns("Name", "Kind", "Fields").
+			Values(noteType.Name, noteType.Kind, string(fields)).
+			RunWith(tx.db).ExecContext(ctx)
+		if err != nil {
+			return writeError("note type", 0, err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, template := range noteType.Templates {
+			_, err := sq.Insert("templates").Columns("NoteTypeId", "Ord", "Name", "Front", "Back").
+				Values(id, template.Ord, template.Name, template.Front, template.Back).
+				RunWith(tx.db).ExecContext(ctx)
+			if err != nil {
+				return writeError("template", 0, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+func (database *Database) GetNoteTypes(ctx context.Context) ([]*models.NoteType, error) {
+	rows, err := sq.Select("ID", "Name", "Kind", "Fields").From("note_types").OrderBy("ID").RunWith(database.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var noteTypes []*models.NoteType
+	byID := make(map[int]*models.NoteType)
+	for rows.Next() {
+		var fields string
+		noteType := new(models.NoteType)
+		if err := rows.Scan(&noteType.ID, &noteType.Name, &noteType.Kind, &fields); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(fields), &noteType.Fields); err != nil {
+			return nil, fmt.Errorf("note type %d fields: %w", noteType.ID, err)
+		}
+		noteTypes = append(noteTypes, noteType)
+		byID[noteType.ID] = noteType
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	templateRows, err := sq.Select("ID", "NoteTypeId", "Ord", "Name", "Front", "Back").
+		From("templates").OrderBy("NoteTypeId", "Ord").RunWith(database.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer templateRows.Close()
+	for templateRows.Next() {
+		template := new(models.Template)
+		err := templateRows.Scan(&template.ID, &template.NoteTypeID, &template.Ord, &template.Name, &template.Front, &template.Back)
+		if err != nil {
+			return nil, err
+		}
+		if noteType, found := byID[template.NoteTypeID]; found {
+			noteType.Templates = append(noteType.Templates, template)
+		}
+	}
+	return noteTypes, templateRows.Err()
+}
+
+func (database *Database) GetNoteType(ctx context.Context, id int) (*models.NoteType, error) {
+	noteTypes, err := database.GetNoteTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, noteType := range noteTypes {
+		if noteType.ID == id {
+			return noteType, nil
+		}
+	}
+	return nil, notFound("note type", id)
+}
+
+func (database *Database) GetNotes(ctx context.Context, filter NoteFilter) ([]*models.Note, error) {
+	query := sq.Select("ID", "NoteTypeId", "DeckId", "Fields", "CreatedAt", "DeletedOrds").From("notes").RunWith(database.db)
+	if filter.Where != nil {
+		query = query.Where(filter.Where)
+	}
+	rows, err := query.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var notes []*models.Note
+	for rows.Next() {
+		var fields string
+		var createdAt sql.NullInt64
+		var deletedOrds sql.NullString
+		note := new(models.Note)
+		if err := rows.Scan(&note.ID, &note.NoteTypeID, &note.DeckID, &fields, &createdAt, &deletedOrds); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(fields), &note.Fields); err != nil {
+			return nil, fmt.Errorf("note %d fields: %w", note.ID, err)
+		}
+		if deletedOrds.Valid && deletedOrds.String != "" {
+			if err := json.Unmarshal([]byte(deletedOrds.String), &note.DeletedOrds); err != nil {
+				return nil, fmt.Errorf("note %d deleted ordinals: %w", note.ID, err)
+			}
+		}
+		if createdAt.Valid {
+			note.CreatedAt = time.Unix(createdAt.Int64, 0)
+		}
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+func (database *Database) CreateNote(ctx context.Context, noteTypeId, deckId int, fields map[string]string, createdAt time.Time) (int, error) {
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+	result, err := sq.Insert("notes").Columns("NoteTypeId", "DeckId", "Fields", "CreatedAt").
+		Values(noteTypeId, deckId, string(encoded), createdAt.Unix()).
+		RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return 0, writeError("note", 0, err)
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (database *Database) EditNote(ctx context.Context, id int, fields map[string]string) error {
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	result, err := sq.Update("notes").Set("Fields", string(encoded)).Where(sq.Eq{"ID": id}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("note", id, err)
+	}
+	return requireRows(result, "note", id)
+}
+
+// SetDeletedOrds stores the ordinals of the note's cards that were deleted
+// on their own.
+func (database *Database) SetDeletedOrds(ctx context.Context, id int, ords []int) error {
+	encoded, err := json.Marshal(ords)
+	if err != nil {
+		return err
+	}
+	result, err := sq.Update("notes").Set("DeletedOrds", string(encoded)).Where(sq.Eq{"ID": id}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("note", id, err)
+	}
+	return requireRows(result, "note", id)
+}
+
+func (database *Database) DeleteNote(ctx context.Context, id int) error {
+	result, err := sq.Delete("notes").Where(sq.Eq{"ID": id}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("note", id, err)
+	}
+	return requireRows(result, "note", id)
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"memoflash/internal/models"
+	"memoflash/internal/utils"
+	"memoflash/internal/values"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// DefaultPresetName is the name of the preset seeded by InitSchema. Decks
+// without a preset of their own use the first preset.
+const DefaultPresetName = "Default"
+
+// DefaultPreset returns the options a new preset starts with.
+func DefaultPreset() *models.Preset {
+	return &models.Preset{
+		Name:             DefaultPresetName,
+		DesiredRetention: 0.9,
+		MaximumInterval:  36500,
+		NewPerDay:        20,
+		ReviewsPerDay:    200,
+		LearningSteps:    []time.Duration{time.Minute, 10 * time.Minute},
+		LeechThreshold:   values.DefaultLeechThreshold,
+		LeechAction:      values.TagLeech,
+		BuryNew:          true,
+		BuryReview:       true,
+	}
+}
+
+var presetColumns = []string{
+	"ID", "Name", "DesiredRetention", "MaximumInterval", "NewPerDay", "ReviewsPerDay", "LearningSteps",
+	"LeechThreshold", "LeechAction", "BuryNew", "BuryReview", "Weights",
+}
+
+// seedPresets creates the default preset when there is none.
+func (database *Database) seedPresets(ctx context.Context) error {
+	count, err := database.Count(ctx, CounterFilter{Table: "presets"})
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = database.CreatePreset(ctx, DefaultPreset())
+	return err
+}
+
+// legacyDeckOptions are the deck columns that held options before presets,
+// with the value a deck had when the column was missing.
+var legacyDeckOptions = []struct {
+	column       string
+	defaultValue int
+}{
+	{"BuryNew", 1},
+	{"BuryReview", 1},
+	{"LeechThreshold", values.DefaultLeechThreshold},
+	{"LeechAction", int(values.TagLeech)},
+}
+
+// migrateDeckOptions moves the options decks held themselves before presets
+// into presets, then drops their columns. Decks whose options match the
+// default preset keep it; every other deck gets a preset of its own, copied
+// from the default one with the deck's options.
+func (database *Database) migrateDeckOptions(ctx context.Context) error {
+	columns := make([]string, len(legacyDeckOptions))
+	var present []string
+	for i, option := range legacyDeckOptions {
+		found, err := database.hasColumn(ctx, "decks", option.column)
+		if err != nil {
+			return err
+		}
+		columns[i] = fmt.Sprintf("%d", option.defaultValue)
+		if found {
+			columns[i] = fmt.Sprintf("COALESCE(%s, %d)", option.column, option.defaultValue)
+			present = append(present, option.column)
+		}
+	}
+	if len(present) == 0 {
+		return nil
+	}
+	return database.WithTx(ctx, func(tx *Tx) error {
+		base, err := tx.GetPreset(ctx, 0)
+		if err != nil {
+			return err
+		}
+		rows, err := sq.Select(append([]string{"ID", "Title"}, columns...)...).From("decks").
+			Where(sq.Eq{"PresetId": nil}).RunWith(tx.db).QueryContext(ctx)
+		if err != nil {
+			return err
+		}
+		var decks []struct {
+			id      int
+			title   string
+			options models.Preset
+		}
+		for rows.Next() {
+			var deck struct {
+				id      int
+				title   string
+				options models.Preset
+			}
+			var title sql.NullString
+			err := rows.Scan(&deck.id, &title, &deck.options.BuryNew, &deck.options.BuryReview,
+				&deck.options.LeechThreshold, &deck.options.LeechAction)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			deck.title = title.String
+			decks = append(decks, deck)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		names, err := tx.GetPresets(ctx)
+		if err != nil {
+			return err
+		}
+		taken := make(map[string]bool, len(names))
+		for _, preset := range names {
+			taken[preset.Name] = true
+		}
+		for _, deck := range decks {
+			options := deck.options
+			if options.BuryNew == base.BuryNew && options.BuryReview == base.BuryReview &&
+				options.LeechThreshold == base.LeechThreshold && options.LeechAction == base.LeechAction {
+				continue
+			}
+			preset := *base
+			preset.BuryNew, preset.BuryReview = options.BuryNew, options.BuryReview
+			preset.LeechThreshold, preset.LeechAction = options.LeechThreshold, options.LeechAction
+			preset.Name = deck.title + " options"
+			for n := 2; taken[preset.Name]; n++ {
+				preset.Name = fmt.Sprintf("%s options %d", deck.title, n)
+			}
+			taken[preset.Name] = true
+			id, err := tx.CreatePreset(ctx, &preset)
+			if err != nil {
+				return err
+			}
+			if err := tx.SetDeckPreset(ctx, deck.id, id); err != nil {
+				return err
+			}
+		}
+		for _, column := range present {
+			if _, err := tx.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE decks DROP COLUMN %s", column)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetPresets returns every preset ordered by ID, the default one first.
+func (database *Database) GetPresets(ctx context.Context) ([]*models.Preset, error) {
+	rows, err := sq.Select(presetColumns...).From("presets").OrderBy("ID").RunWith(database.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var presets []*models.Preset
+	for rows.Next() {
+		var steps, weights sql.NullString
+		preset := new(models.Preset)
+		err := rows.Scan(&preset.ID, &preset.Name, &preset.DesiredRetention, &preset.MaximumInterval, &preset.NewPerDay, &preset.ReviewsPerDay,
+			&steps, &preset.LeechThreshold, &preset.LeechAction, &preset.BuryNew, &preset.BuryReview, &weights)
+		if err != nil {
+			return nil, err
+		}
+		if preset.LearningSteps, err = utils.ParseSteps(steps.String); err != nil {
+			return nil, fmt.Errorf("preset %d learning steps: %w", preset.ID, err)
+		}
+		if weights.String != "" {
+			if err := json.Unmarshal([]byte(weights.String), &preset.Weights); err != nil {
+				return nil, fmt.Errorf("preset %d weights: %w", preset.ID, err)
+			}
+		}
+		presets = append(presets, preset)
+	}
+	return presets, rows.Err()
+}
+
+// GetPreset returns the preset with id, or the default preset when id is zero
+// or no longer exists.
+func (database *Database) GetPreset(ctx context.Context, id int) (*models.Preset, error) {
+	presets, err := database.GetPresets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(presets) == 0 {
+		return DefaultPreset(), nil
+	}
+	for _, preset := range presets {
+		if preset.ID == id {
+			return preset, nil
+		}
+	}
+	return presets[0], nil
+}
+
+func (database *Database) CreatePreset(ctx context.Context, preset *models.Preset) (int, error) {
+	weights, err := json.Marshal(preset.Weights)
+	if err != nil {
+		return 0, err
+	}
+	result, err := sq.Insert("presets").Columns(presetColumns[1:]...).
+		Values(preset.Name, preset.DesiredRetention, preset.MaximumInterval, preset.NewPerDay, preset.ReviewsPerDay,
+			utils.FormatSteps(preset.LearningSteps), preset.LeechThreshold, preset.LeechAction, preset.BuryNew, preset.BuryReview, string(weights)).
+		RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return 0, writeError("preset", 0, err)
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (database *Database) EditPreset(ctx context.Context, preset *models.Preset) error {
+	weights, err := json.Marshal(preset.Weights)
+	if err != nil {
+		return err
+	}
+	result, err := sq.Update("presets").
+		Set("Name", preset.Name).
+		Set("DesiredRetention", preset.DesiredRetention).
+		Set("MaximumInterval", preset.MaximumInterval).
+		Set("NewPerDay", preset.NewPerDay).
+		Set("ReviewsPerDay", preset.ReviewsPerDay).
+		Set("LearningSteps", utils.FormatSteps(preset.LearningSteps)).
+		Set("LeechThreshold", preset.LeechThreshold).
+		Set("LeechAction", preset.LeechAction).
+		Set("BuryNew", preset.BuryNew).
+		Set("BuryReview", preset.BuryReview).
+		Set("Weights", string(weights)).
+		Where(sq.Eq{"ID": preset.ID}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("preset", preset.ID, err)
+	}
+	return requireRows(result, "preset", preset.ID)
+}
+
+// DeletePreset removes the preset. Its decks fall back to the default preset.
+func (database *Database) DeletePreset(ctx context.Context, id int) error {
+	result, err := sq.Delete("presets").Where(sq.Eq{"ID": id}).RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("preset", id, err)
+	}
+	return requireRows(result, "preset", id)
+}
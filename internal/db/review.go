@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"memoflash/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ReviewCount holds how many new and review cards were answered.
+type ReviewCount struct {
+	New    int
+	Review int
+}
+
+// AddReview logs a review. The answer duration is stored in milliseconds.
+func (database *Database) AddReview(ctx context.Context, review *models.Review) error {
+	_, err := sq.Insert("reviews").Columns("CardId", "DeckId", "Rating", "WasNew", "ReviewedAt", "Duration").
+		Values(review.CardID, review.DeckID, review.Rating, review.WasNew, review.ReviewedAt.Unix(), review.Duration.Milliseconds()).
+		RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return writeError("review", 0, err)
+	}
+	return nil
+}
+
+// DeleteLastReview removes the latest review of the card from the log.
+func (database *Database) DeleteLastReview(ctx context.Context, cardId int) error {
+	_, err := sq.Delete("reviews").
+		Where("ID = (SELECT MAX(ID) FROM reviews WHERE CardId = ?)", cardId).
+		RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("Error Executing Statement: %w", err)
+	}
+	return nil
+}
+
+// CountReviewsByDeck counts the distinct cards answered since the given time,
+// keyed by deck.
+func (database *Database) CountReviewsByDeck(ctx context.Context, since time.Time) (map[int]*ReviewCount, error) {
+	rows, err := sq.Select("DeckId", "WasNew", "COUNT(DISTINCT CardId)").From("reviews").
+		Where(sq.GtOrEq{"ReviewedAt": since.Unix()}).GroupBy("DeckId", "WasNew").
+		RunWith(database.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[int]*ReviewCount)
+	for rows.Next() {
+		var deckId sql.NullInt64
+		var wasNew bool
+		var count int
+		if err := rows.Scan(&deckId, &wasNew, &count); err != nil {
+			return nil, err
+		}
+		id := int(deckId.Int64)
+		if counts[id] == nil {
+			counts[id] = new(ReviewCount)
+		}
+		if wasNew {
+			counts[id].New += count
+		} else {
+			counts[id].Review += count
+		}
+	}
+	return counts, rows.Err()
+}
+
+// ReviewBucket holds the number of reviews logged within the minute that
+// starts at At, and the time spent answering them.
+type ReviewBucket struct {
+	At       time.Time
+	Reviews  int
+	Duration time.Duration
+}
+
+// CountReviewsByMinute counts the reviews logged since the given time in
+// one-minute buckets, oldest first. Minutes are fine enough for callers to
+// group the buckets into days of any time zone.
+func (database *Database) CountReviewsByMinute(ctx context.Context, since time.Time) ([]ReviewBucket, error) {
+	rows, err := sq.Select("ReviewedAt / 60 AS minute", "COUNT(*)", "SUM(Duration)").From("reviews").
+		Where(sq.GtOrEq{"ReviewedAt": since.Unix()}).GroupBy("minute").OrderBy("minute").
+		RunWith(database.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var buckets []ReviewBucket
+	for rows.Next() {
+		var minute int64
+		var duration sql.NullInt64
+		var bucket ReviewBucket
+		if err := rows.Scan(&minute, &bucket.Reviews, &duration); err != nil {
+			return nil, err
+		}
+		bucket.At = time.Unix(minute*60, 0)
+		bucket.Duration = time.Duration(duration.Int64) * time.Millisecond
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
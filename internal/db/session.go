@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"memoflash/internal/models"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var sessionColumns = []string{
+	"ID", "DeckId", "Mode", "StartedAt", "EndedAt", "Cards", "Answers",
+	"Again", "Hard", "Good", "Easy", "Reviewed", "Lapses",
+}
+
+func (database *Database) AddStudySession(ctx context.Context, session *models.StudySession) (int, error) {
+	var deckId any
+	if session.DeckID != 0 {
+		deckId = session.DeckID
+	}
+	result, err := sq.Insert("study_sessions").Columns(sessionColumns[1:]...).
+		Values(deckId, session.Mode, session.StartedAt.Unix(), session.EndedAt.Unix(),
+			session.Cards, session.Answers, session.Again, session.Hard, session.Good, session.Easy,
+			session.Reviewed, session.Lapses).
+		RunWith(database.db).ExecContext(ctx)
+	if err != nil {
+		return 0, writeError("study session", 0, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// GetStudySessions returns the latest sessions first, at most limit of them
+// when limit is not zero.
+func (database *Database) GetStudySessions(ctx context.Context, limit uint64) ([]*models.StudySession, error) {
+	query := sq.Select(sessionColumns...).From("study_sessions").OrderBy("StartedAt DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	rows, err := query.RunWith(database.db).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []*models.StudySession
+	for rows.Next() {
+		session := new(models.StudySession)
+		var deckId sql.NullInt64
+		var startedAt, endedAt int64
+		if err := rows.Scan(&session.ID, &deckId, &session.Mode, &startedAt, &endedAt,
+			&session.Cards, &session.Answers, &session.Again, &session.Hard, &session.Good, &session.Easy,
+			&session.Reviewed, &session.Lapses); err != nil {
+			return nil, err
+		}
+		session.DeckID = int(deckId.Int64)
+		session.StartedAt = time.Unix(startedAt, 0)
+		session.EndedAt = time.Unix(endedAt, 0)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// NextDue returns the earliest interval of a scheduled, unsuspended card
+// from from on, in deckId or in every deck when deckId is zero. It returns
+// the zero time when no card is scheduled that late.
+func (database *Database) NextDue(ctx context.Context, deckId int, from time.Time) (time.Time, error) {
+	where := sq.And{sq.Eq{"Suspended": false}, sq.GtOrEq{"Interval": from.Unix()}}
+	if deckId != 0 {
+		where = append(where, sq.Eq{"ParentDeckId": deckId})
+	}
+	var next sql.NullInt64
+	err := sq.Select("MIN(Interval)").From("cards").Where(where).
+		RunWith(database.db).QueryRowContext(ctx).Scan(&next)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !next.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(next.Int64, 0), nil
+}
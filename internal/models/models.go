@@ -2,21 +2,14 @@ package models
 
 import (
 	"image/color"
+	"memoflash/internal/values"
+	"slices"
+	"strings"
 	"time"
 
 	"cogentcore.org/core/icons"
 )
 
-// `CREATE TABLE IF NOT EXISTS states (
-// 			dayStreak       INTEGER DEFAULT 0,
-// 			lastTimeUpdated INTEGER DEFAULT 0
-// 		)`,
-
-type States struct {
-	DayStreak       int       `db:"dayStreak"`
-	LastTimeUpdated time.Time `db:"lastTimeUpdated"`
-}
-
 type Deck struct {
 	ID            int
 	Title         string
@@ -27,6 +20,98 @@ type Deck struct {
 	TotalCards    int
 	DueCards      int
 	CreatedAt     time.Time
+	// PresetID is the option preset the deck is scheduled with; zero means
+	// the default preset.
+	PresetID int
+}
+
+// Preset is a named set of scheduling options shared by decks.
+type Preset struct {
+	ID               int
+	Name             string
+	DesiredRetention float64
+	// MaximumInterval caps the interval between reviews, in days.
+	MaximumInterval int
+	// NewPerDay and ReviewsPerDay limit how many new and review cards of a
+	// deck are studied each day.
+	NewPerDay     int
+	ReviewsPerDay int
+	LearningSteps []time.Duration
+	// LeechThreshold is the number of lapses after which a card becomes a
+	// leech; LeechAction says what happens to it then.
+	LeechThreshold int
+	LeechAction    values.LeechAction
+	// BuryNew and BuryReview hide the siblings of a card reviewed today
+	// until the next day, for new and review siblings respectively.
+	BuryNew    bool
+	BuryReview bool
+	// Weights are the FSRS weights; empty means the defaults.
+	Weights []float64
+}
+
+// Review is one answer given to a card.
+type Review struct {
+	ID         int
+	CardID     int
+	DeckID     int
+	Rating     values.Difficulty
+	WasNew     bool
+	ReviewedAt time.Time
+	// Duration is the time taken to answer, from showing the card to
+	// rating it.
+	Duration time.Duration
+}
+
+// Goal is a daily study target, in effect from the learning day starting at
+// StartsAt until the next goal is set. A zero Target means no goal.
+type Goal struct {
+	ID       int
+	Kind     values.GoalKind
+	Target   int
+	StartsAt time.Time
+}
+
+// DailyProgress is what was studied on the learning day starting at Day.
+type DailyProgress struct {
+	Day      time.Time
+	Reviews  int
+	Duration time.Duration
+}
+
+// StudySession records one finished study session.
+type StudySession struct {
+	ID int
+	// DeckID is zero when the session mixed cards of several decks.
+	DeckID    int
+	Mode      values.SessionMode
+	StartedAt time.Time
+	EndedAt   time.Time
+	// Cards counts the distinct cards answered and Answers every answer,
+	// including repeats of cards rated Again.
+	Cards   int
+	Answers int
+	Again   int
+	Hard    int
+	Good    int
+	Easy    int
+	// Reviewed counts the answers to cards that were already learned and
+	// Lapses those of them rated Again.
+	Reviewed int
+	Lapses   int
+}
+
+// Duration is the time spent in the session.
+func (session *StudySession) Duration() time.Duration {
+	return session.EndedAt.Sub(session.StartedAt)
+}
+
+// Retention is the share of answers to learned cards that were not rated
+// Again, or -1 when no learned card was answered.
+func (session *StudySession) Retention() float64 {
+	if session.Reviewed == 0 {
+		return -1
+	}
+	return float64(session.Reviewed-session.Lapses) / float64(session.Reviewed)
 }
 
 type StatsCard struct {
@@ -44,8 +129,88 @@ type Card struct {
 	Stability    float64   `db:"Stability"`
 	Difficulty   float64   `db:"Difficulty"`
 	Interval     time.Time `db:"Interval"`
+	NoteID       int       `db:"NoteId"`
+	Ord          int       `db:"Ord"`
+	BuriedUntil  time.Time `db:"BuriedUntil"`
+	// Suspended cards are left out of every queue until unsuspended.
+	Suspended bool        `db:"Suspended"`
+	Flag      values.Flag `db:"Flag"`
+	// Lapses counts how often the card was forgotten after being learned.
+	Lapses int      `db:"Lapses"`
+	Tags   []string `db:"Tags"`
+	// TypeAnswer asks the learner to type the back before it is revealed.
+	TypeAnswer bool `db:"TypeAnswer"`
+	// Step is the learning step the card is waiting on, counted from one;
+	// zero means the card is not in learning.
+	Step int `db:"Step"`
+}
+
+// LeechTag marks cards that were forgotten too often.
+const LeechTag = "leech"
+
+func (card *Card) HasTag(tag string) bool {
+	return slices.ContainsFunc(card.Tags, func(cardTag string) bool {
+		return strings.EqualFold(cardTag, tag)
+	})
+}
+
+func (card *Card) IsLeech() bool {
+	return card.HasTag(LeechTag)
+}
+
+// IsDue reports whether the card is to be studied before dayEnd, the start
+// of the next learning day, as seen at now.
+func (card *Card) IsDue(now, dayEnd time.Time) bool {
+	return !card.Suspended && !card.IsBuried(now) && (card.Interval.IsZero() || card.Interval.Before(dayEnd))
+}
+
+// IsNew reports whether the card has never been scheduled.
+func (card *Card) IsNew() bool {
+	return card.Interval.IsZero()
+}
+
+// IsBuried reports whether the card is still buried at now.
+func (card *Card) IsBuried(now time.Time) bool {
+	return card.BuriedUntil.After(now)
+}
+
+type NoteKind int
+
+const (
+	// StandardNote generates one card per template.
+	StandardNote NoteKind = iota
+	// ClozeNote generates one card per cloze number found in its fields.
+	ClozeNote
+)
+
+// NoteType describes the named fields a note carries and the templates
+// that turn those fields into cards.
+type NoteType struct {
+	ID        int
+	Name      string
+	Kind      NoteKind
+	Fields    []string
+	Templates []*Template
+}
+
+// Template renders one card of a note. Front and Back reference note fields
+// with {{Field}}; Back may also use {{FrontSide}}.
+type Template struct {
+	ID         int
+	NoteTypeID int
+	Ord        int
+	Name       string
+	Front      string
+	Back       string
 }
 
-func (card *Card) IsDue() bool {
-	return card.Interval.IsZero() || card.Interval.Before(time.Now())
+type Note struct {
+	ID         int
+	NoteTypeID int
+	DeckID     int
+	Fields     map[string]string
+	CreatedAt  time.Time
+	// DeletedOrds are the ordinals of the cards deleted on their own, which
+	// are not generated again when the note changes.
+	DeletedOrds []int
 }
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"memoflash/internal/db"
+	"memoflash/pkg/clock"
+	"time"
+)
+
+// Activity sums up the learning days on which cards were reviewed.
+type Activity struct {
+	// CurrentStreak is the number of days studied in the streak still going:
+	// one that ends today, or yesterday while today hasn't been studied yet.
+	CurrentStreak int
+	// LongestStreak is the number of days studied in the longest streak.
+	LongestStreak int
+	// DaysStudied is the number of days with at least one review.
+	DaysStudied int
+	// StudiedToday reports whether a card has been reviewed today.
+	StudiedToday bool
+	// FreezesLeft is the number of days the current streak may still miss
+	// without breaking.
+	FreezesLeft int
+}
+
+// DailyCount is the number of reviews logged on the learning day starting
+// at Day.
+type DailyCount struct {
+	Day     time.Time
+	Reviews int
+}
+
+type ActivityService interface {
+	GetActivity(ctx context.Context, freezes int) (*Activity, error)
+	GetDailyCounts(ctx context.Context, days int) ([]DailyCount, error)
+}
+
+type activityService struct {
+	db    *db.Database
+	clock *clock.Calendar
+}
+
+func NewActivityService(db *db.Database, clock *clock.Calendar) ActivityService {
+	return &activityService{db: db, clock: clock}
+}
+
+// GetActivity works out the streaks from the review log. A streak may skip
+// up to freezes days in total; skipping one more breaks it.
+func (as *activityService) GetActivity(ctx context.Context, freezes int) (*Activity, error) {
+	counts, err := as.countByDay(ctx, time.Unix(0, 0))
+	if err != nil {
+		return nil, err
+	}
+	freezes = max(freezes, 0)
+	activity := &Activity{DaysStudied: len(counts), FreezesLeft: freezes}
+	if len(counts) == 0 {
+		return activity, nil
+	}
+	var streak, used int
+	for i, count := range counts {
+		if i > 0 {
+			missed := daysBetween(counts[i-1].Day, count.Day) - 1
+			if missed > freezes-used {
+				streak, used = 0, 0
+			} else {
+				used += missed
+			}
+		}
+		streak++
+		activity.LongestStreak = max(activity.LongestStreak, streak)
+	}
+	today := as.clock.Today()
+	last := counts[len(counts)-1].Day
+	activity.StudiedToday = last.Equal(today)
+	// Today is not missed until it is over.
+	if missed := max(daysBetween(last, today)-1, 0); missed <= freezes-used {
+		activity.CurrentStreak = streak
+		activity.FreezesLeft = freezes - used - missed
+	}
+	return activity, nil
+}
+
+// GetDailyCounts returns the reviews of each of the last days learning days,
+// today included, oldest first. Days without reviews are counted as zero.
+func (as *activityService) GetDailyCounts(ctx context.Context, days int) ([]DailyCount, error) {
+	if days <= 0 {
+		return nil, nil
+	}
+	first := as.clock.Today().AddDate(0, 0, 1-days)
+	counts, err := as.countByDay(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	daily := make([]DailyCount, days)
+	for i := range daily {
+		daily[i].Day = as.clock.DayStart(first.AddDate(0, 0, i))
+	}
+	for _, count := range counts {
+		if i := daysBetween(first, count.Day); i >= 0 && i < days {
+			daily[i].Reviews = count.Reviews
+		}
+	}
+	return daily, nil
+}
+
+// countByDay returns the reviews of each learning day since the given time
+// that has any, oldest first.
+func (as *activityService) countByDay(ctx context.Context, since time.Time) ([]DailyCount, error) {
+	buckets, err := as.db.CountReviewsByMinute(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	var counts []DailyCount
+	for _, bucket := range buckets {
+		day := as.clock.DayStart(bucket.At)
+		if len(counts) == 0 || !counts[len(counts)-1].Day.Equal(day) {
+			counts = append(counts, DailyCount{Day: day})
+		}
+		counts[len(counts)-1].Reviews += bucket.Reviews
+	}
+	return counts, nil
+}
+
+// daysBetween returns the number of calendar days from the day of a to the
+// day of b, ignoring daylight saving changes in between.
+func daysBetween(a, b time.Time) int {
+	dayNumber := func(t time.Time) int {
+		year, month, day := t.Date()
+		return int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+	}
+	return dayNumber(b) - dayNumber(a)
+}
@@ -0,0 +1,158 @@
+package services_test
+
+import (
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/services"
+	"memoflash/internal/values"
+	"testing"
+	"time"
+)
+
+// addReviews logs a review at each of times.
+func addReviews(t *testing.T, f *fixture, times ...time.Time) {
+	t.Helper()
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	card := dbtest.Card(deck.ID).Add(t, f.db)
+	for _, at := range times {
+		dbtest.AddReview(t, f.db, card, values.Good, at)
+	}
+}
+
+// studied returns mid-afternoon of each day, given as a number of days after
+// now.
+func studied(days ...int) []time.Time {
+	times := make([]time.Time, len(days))
+	for i, n := range days {
+		times[i] = day(n, 15, 0)
+	}
+	return times
+}
+
+func TestGetActivity(t *testing.T) {
+	tests := []struct {
+		name    string
+		reviews []time.Time
+		freezes int
+		want    services.Activity
+	}{
+		{name: "nothing studied", freezes: 1, want: services.Activity{FreezesLeft: 1}},
+		{
+			name:    "studied today",
+			reviews: studied(0, 0),
+			want:    services.Activity{CurrentStreak: 1, LongestStreak: 1, DaysStudied: 1, StudiedToday: true},
+		},
+		{
+			name:    "today not studied yet",
+			reviews: studied(-2, -1),
+			want:    services.Activity{CurrentStreak: 2, LongestStreak: 2, DaysStudied: 2},
+		},
+		{
+			name:    "yesterday missed",
+			reviews: studied(-3, -2),
+			want:    services.Activity{LongestStreak: 2, DaysStudied: 2},
+		},
+		{
+			name:    "yesterday frozen",
+			reviews: studied(-3, -2),
+			freezes: 1,
+			want:    services.Activity{CurrentStreak: 2, LongestStreak: 2, DaysStudied: 2},
+		},
+		{
+			name:    "after midnight counts for the day before",
+			reviews: []time.Time{day(-2, 15, 0), day(0, rolloverHour-1, 0)},
+			want:    services.Activity{CurrentStreak: 2, LongestStreak: 2, DaysStudied: 2},
+		},
+		{
+			name:    "after the rollover counts for today",
+			reviews: []time.Time{day(-2, 15, 0), day(0, rolloverHour, 0)},
+			want:    services.Activity{CurrentStreak: 1, LongestStreak: 1, DaysStudied: 2, StudiedToday: true},
+		},
+		{
+			name:    "broken streak",
+			reviews: studied(-7, -6, -5, -4, -2, -1, 0),
+			want:    services.Activity{CurrentStreak: 3, LongestStreak: 4, DaysStudied: 7, StudiedToday: true},
+		},
+		{
+			name:    "gap frozen",
+			reviews: studied(-4, -2, -1, 0),
+			freezes: 2,
+			want:    services.Activity{CurrentStreak: 4, LongestStreak: 4, DaysStudied: 4, StudiedToday: true, FreezesLeft: 1},
+		},
+		{
+			name:    "freezes run out",
+			reviews: studied(-6, -4, -2, 0),
+			freezes: 2,
+			want:    services.Activity{CurrentStreak: 1, LongestStreak: 3, DaysStudied: 4, StudiedToday: true, FreezesLeft: 2},
+		},
+		{
+			name:    "gap longer than the freezes",
+			reviews: studied(-5, -1),
+			freezes: 2,
+			want:    services.Activity{CurrentStreak: 1, LongestStreak: 1, DaysStudied: 2, FreezesLeft: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			addReviews(t, f, tt.reviews...)
+			got, err := f.activity.GetActivity(ctx, tt.freezes)
+			checkError(t, err, nil)
+			if *got != tt.want {
+				t.Errorf("GetActivity() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// TestActivityAcrossDays checks that the streak stays alive through the day
+// after the last review and ends once that day is over.
+func TestActivityAcrossDays(t *testing.T) {
+	f := newFixture(t)
+	addReviews(t, f, studied(-1, 0)...)
+
+	steps := []struct {
+		at           time.Time
+		wantStreak   int
+		studiedToday bool
+	}{
+		{at: day(0, 23, 59), wantStreak: 2, studiedToday: true},
+		{at: day(1, rolloverHour-1, 59), wantStreak: 2, studiedToday: true},
+		{at: tomorrow, wantStreak: 2},
+		{at: day(1, 23, 0), wantStreak: 2},
+		{at: day(2, rolloverHour, 0), wantStreak: 0},
+	}
+	for _, step := range steps {
+		f.clock.Set(step.at)
+		got, err := f.activity.GetActivity(ctx, 0)
+		checkError(t, err, nil)
+		if got.CurrentStreak != step.wantStreak || got.StudiedToday != step.studiedToday {
+			t.Errorf("at %v: streak, studied today = %d, %v, want %d, %v",
+				step.at, got.CurrentStreak, got.StudiedToday, step.wantStreak, step.studiedToday)
+		}
+	}
+}
+
+func TestGetDailyCounts(t *testing.T) {
+	f := newFixture(t)
+	addReviews(t, f,
+		day(-5, 12, 0),
+		day(-2, 9, 0), day(-2, 22, 0), day(-1, rolloverHour-1, 0),
+		day(0, rolloverHour, 0), day(0, 15, 0),
+	)
+
+	counts, err := f.activity.GetDailyCounts(ctx, 3)
+	checkError(t, err, nil)
+	want := []services.DailyCount{
+		{Day: day(-2, rolloverHour, 0), Reviews: 3},
+		{Day: day(-1, rolloverHour, 0), Reviews: 0},
+		{Day: day(0, rolloverHour, 0), Reviews: 2},
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("GetDailyCounts() = %d days, want %d", len(counts), len(want))
+	}
+	for i := range want {
+		if !counts[i].Day.Equal(want[i].Day) || counts[i].Reviews != want[i].Reviews {
+			t.Errorf("day %d = %v: %d, want %v: %d", i, counts[i].Day, counts[i].Reviews, want[i].Day, want[i].Reviews)
+		}
+	}
+}
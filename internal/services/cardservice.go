@@ -1,47 +1,61 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"memoflash/internal/db"
 	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"memoflash/pkg/clock"
+	"memoflash/pkg/fsrs"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/Masterminds/squirrel"
 	sq "github.com/Masterminds/squirrel"
 )
 
 type CardService interface {
-	CreateCard(Front string, Back string, deckId int) error
-	DeleteCard(id int) error
-	CountDueCardsFromDeck(deckId int) (int, error)
-	GetStreak() (int, error)
-	GetTotalCardsInDeck(deckId int) (int, error)
-	GetDueCardsFromDeck(deckId int) ([]*models.Card, error)
-	CountDueCards() (int, error)
-	GetAllDueCards() ([]*models.Card, error)
-	GetProgress() (int, error)
-	GetCardsByDeck(deckId int) ([]*models.Card, error)
-	EditCard(id int, Front string, Back string) error
+	CreateCard(ctx context.Context, Front string, Back string, deckId int, reversed, typeAnswer bool) ([]*models.Card, error)
+	DeleteCard(ctx context.Context, id int) error
+	CountDueCardsFromDeck(ctx context.Context, deckId int) (int, error)
+	GetTotalCardsInDeck(ctx context.Context, deckId int) (int, error)
+	GetDueCardsFromDeck(ctx context.Context, deckId int) ([]*models.Card, error)
+	CountDueCards(ctx context.Context) (int, error)
+	GetAllDueCards(ctx context.Context, limit int) ([]*models.Card, error)
+	GetProgress(ctx context.Context) (int, error)
+	GetCardsByDeck(ctx context.Context, deckId int) ([]*models.Card, error)
+	EditCard(ctx context.Context, id int, Front string, Back string, typeAnswer bool) error
+	ReviewCard(ctx context.Context, card *models.Card, rating values.Difficulty, duration time.Duration) (bool, error)
+	UndoReview(ctx context.Context, before *models.Card) error
+	SuspendCard(ctx context.Context, id int, suspended bool) error
+	BuryCard(ctx context.Context, id int, buried bool) error
+	FlagCard(ctx context.Context, id int, flag values.Flag) error
+	SetTypeAnswer(ctx context.Context, id int, typeAnswer bool) error
+	GetCustomStudyCards(ctx context.Context, study CustomStudy) ([]*models.Card, error)
+	GetQuiz(ctx context.Context, deckId int) ([]*QuizQuestion, error)
 }
 type cardService struct {
-	db *db.Database
+	db    *db.Database
+	clock *clock.Calendar
 }
 
-func NewCardService(db *db.Database) *cardService {
-	return &cardService{db: db}
+func NewCardService(db *db.Database, clock *clock.Calendar) *cardService {
+	return &cardService{db: db, clock: clock}
 }
-func (cs *cardService) GetCards() ([]*models.Card, error) {
-	return cs.db.GetCards(db.CardFilter{})
+func (cs *cardService) GetCards(ctx context.Context) ([]*models.Card, error) {
+	return cs.db.GetCards(ctx, db.CardFilter{})
 }
-func (cs *cardService) GetProgress() (int, error) {
-	totalCards, err := cs.db.Count(db.CounterFilter{
+func (cs *cardService) GetProgress(ctx context.Context) (int, error) {
+	totalCards, err := cs.db.Count(ctx, db.CounterFilter{
 		Table: "cards",
 	})
 	if err != nil {
 		return 0, err
 	}
-	progress, err := cs.db.Count(db.CounterFilter{
+	progress, err := cs.db.Count(ctx, db.CounterFilter{
 		Table:     "cards",
-		Condition: squirrel.NotEq{"interval": nil},
+		Condition: sq.NotEq{"interval": nil},
 	})
 	if err != nil {
 		return 0, err
@@ -55,104 +69,371 @@ func (cs *cardService) GetProgress() (int, error) {
 	return ProgressPercentage, nil
 }
 
-func (cs *cardService) GetAllDueCards() ([]*models.Card, error) {
-	return cs.db.GetCards(db.CardFilter{
-		Order: "interval ASC",
-		Where: squirrel.Or{
-			squirrel.Eq{"interval": nil},
-			squirrel.LtOrEq{"date(interval,'unixepoch')": time.Now().Format("2006-01-02")}},
-	})
+// dueCondition matches cards that are due before the next learning day of
+// calendar starts and are neither suspended nor buried.
+func dueCondition(calendar *clock.Calendar) sq.And {
+	return sq.And{
+		sq.Eq{"Suspended": false},
+		sq.Or{
+			sq.Eq{"interval": nil},
+			sq.Lt{"interval": calendar.Tomorrow().Unix()},
+		},
+		sq.Or{
+			sq.Eq{"BuriedUntil": nil},
+			sq.LtOrEq{"BuriedUntil": calendar.Now().Unix()},
+		},
+	}
 }
-func (ds *cardService) GetTotalCardsInDeck(deckid int) (int, error) {
-	count, err := ds.db.Count(db.CounterFilter{
+
+// GetAllDueCards returns the study queue of every deck, each cut at its
+// preset's limits. A positive limit then caps the whole queue, a ceiling
+// across decks on top of their own limits.
+func (cs *cardService) GetAllDueCards(ctx context.Context, limit int) ([]*models.Card, error) {
+	queue, err := dueQueue(ctx, cs.db, cs.clock, nil)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(queue) > limit {
+		queue = queue[:limit]
+	}
+	return queue, nil
+}
+func (ds *cardService) GetTotalCardsInDeck(ctx context.Context, deckid int) (int, error) {
+	count, err := ds.db.Count(ctx, db.CounterFilter{
 		Condition: sq.Eq{"ParentDeckId": deckid},
 		Table:     "cards",
 	})
 	return int(count), err
 }
 
-func (cs *cardService) CreateCard(Front string, Back string, deckId int) error {
-	return cs.db.CreateCard(Front, Back, deckId)
+// CreateCard adds a card to the deck. When reversed is set the card is backed
+// by a note that also generates a back-to-front sibling. typeAnswer makes
+// every card ask for a typed answer. It returns every card created.
+func (cs *cardService) CreateCard(ctx context.Context, Front string, Back string, deckId int, reversed, typeAnswer bool) ([]*models.Card, error) {
+	if strings.TrimSpace(Front) == "" {
+		return nil, invalidInput("card", 0, "the front is empty")
+	}
+	var cards []*models.Card
+	err := cs.db.WithTx(ctx, func(tx *db.Tx) error {
+		if reversed {
+			noteType, err := findNoteType(ctx, tx.Database, func(nt *models.NoteType) bool {
+				return nt.Name == db.ReversedNoteType
+			})
+			if err != nil {
+				return err
+			}
+			cards, err = createNote(ctx, tx.Database, noteType, deckId, map[string]string{"Front": Front, "Back": Back}, cs.clock.Now())
+			if err != nil {
+				return err
+			}
+		} else {
+			card := &models.Card{Front: Front, Back: Back, ParentDeckId: deckId}
+			id, err := tx.AddCard(ctx, card)
+			if err != nil {
+				return err
+			}
+			card.ID = id
+			cards = []*models.Card{card}
+		}
+		if !typeAnswer {
+			return nil
+		}
+		ids := make([]int, len(cards))
+		for i, card := range cards {
+			ids[i] = card.ID
+			card.TypeAnswer = true
+		}
+		return tx.SetTypeAnswer(ctx, ids, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
 }
 
-func (cs *cardService) DeleteCard(id int) error {
-	return cs.db.DeleteCard(db.CardFilter{
-		Where: sq.Eq{"ID": id},
+// DeleteCard removes the card. A card generated from a note is remembered as
+// deleted, so that editing the note does not bring it back.
+func (cs *cardService) DeleteCard(ctx context.Context, id int) error {
+	return cs.db.WithTx(ctx, func(tx *db.Tx) error {
+		cards, err := tx.GetCards(ctx, db.CardFilter{Where: sq.Eq{"ID": id}})
+		if err != nil {
+			return err
+		}
+		if len(cards) > 0 && cards[0].NoteID != 0 {
+			note, err := getNote(ctx, tx.Database, cards[0].NoteID)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(note.DeletedOrds, cards[0].Ord) {
+				if err := tx.SetDeletedOrds(ctx, note.ID, append(note.DeletedOrds, cards[0].Ord)); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.DeleteCard(ctx, db.CardFilter{
+			Where: sq.Eq{"ID": id},
+		})
 	})
 }
-func (cs *cardService) GetCardsByDeck(deckId int) ([]*models.Card, error) {
-	return cs.db.GetCards(db.CardFilter{
+func (cs *cardService) GetCardsByDeck(ctx context.Context, deckId int) ([]*models.Card, error) {
+	return cs.db.GetCards(ctx, db.CardFilter{
 		Where: sq.Eq{"ParentDeckId": deckId},
 	})
 }
-func (cs *cardService) GetDueCardsFromDeck(deckId int) ([]*models.Card, error) {
-	return cs.db.GetCards(db.CardFilter{
+func (cs *cardService) GetDueCardsFromDeck(ctx context.Context, deckId int) ([]*models.Card, error) {
+	return dueQueue(ctx, cs.db, cs.clock, sq.Eq{"ParentDeckId": deckId})
+}
+
+// dueQueue returns the due cards matching where, or every due card when
+// where is nil, in the order they are studied. Every study queue and due
+// count goes through it, so that they agree with each other.
+func dueQueue(ctx context.Context, database *db.Database, calendar *clock.Calendar, where sq.Sqlizer) ([]*models.Card, error) {
+	condition := sq.And{dueCondition(calendar)}
+	if where != nil {
+		condition = append(condition, where)
+	}
+	cards, err := database.GetCards(ctx, db.CardFilter{
 		Order: "interval ASC",
-		Where: squirrel.And{
-			squirrel.Eq{"ParentDeckId": deckId},
-			squirrel.Or{
-				squirrel.Eq{"interval": nil},
-				squirrel.LtOrEq{"date(interval,'unixepoch')": time.Now().Format("2006-01-02")},
-			},
-		},
+		Where: condition,
 	})
+	if err != nil {
+		return nil, err
+	}
+	return buildQueue(ctx, database, calendar, cards)
+}
+
+// buildQueue keeps only the first card of each note in a queue when the
+// card's preset buries siblings, so related cards are spread over separate
+// days, and stops each deck at the new and review limits of its preset.
+func buildQueue(ctx context.Context, database *db.Database, calendar *clock.Calendar, cards []*models.Card) ([]*models.Card, error) {
+	presets, fallback, err := deckPresets(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	studied, err := database.CountReviewsByDeck(ctx, calendar.Today())
+	if err != nil {
+		return nil, err
+	}
+	queued := make(map[int]bool)
+	left := make(map[int]*db.ReviewCount)
+	queue := make([]*models.Card, 0, len(cards))
+	for _, card := range cards {
+		preset, found := presets[card.ParentDeckId]
+		if !found {
+			preset = fallback
+		}
+		if card.NoteID != 0 && queued[card.NoteID] && buriesSibling(preset, card) {
+			continue
+		}
+		limit, found := left[card.ParentDeckId]
+		if !found {
+			limit = &db.ReviewCount{New: preset.NewPerDay, Review: preset.ReviewsPerDay}
+			if count := studied[card.ParentDeckId]; count != nil {
+				limit.New -= count.New
+				limit.Review -= count.Review
+			}
+			left[card.ParentDeckId] = limit
+		}
+		remaining := &limit.Review
+		if card.IsNew() {
+			remaining = &limit.New
+		}
+		if *remaining <= 0 {
+			continue
+		}
+		*remaining--
+		queued[card.NoteID] = true
+		queue = append(queue, card)
+	}
+	return queue, nil
 }
 
-func (cs *cardService) EditCard(id int, Front string, Back string) error {
-	return cs.db.EditCard(Front, Back, id)
+// buriesSibling reports whether preset settings bury sibling.
+func buriesSibling(preset *models.Preset, sibling *models.Card) bool {
+	if preset == nil {
+		return false
+	}
+	if sibling.IsNew() {
+		return preset.BuryNew
+	}
+	return preset.BuryReview
 }
 
-func (cs *cardService) CountDueCards() (int, error) {
-	counts, err := cs.db.Count(db.CounterFilter{
-		Condition: squirrel.Or{
-			squirrel.Eq{"interval": nil},
-			squirrel.LtOrEq{"date(interval,'unixepoch')": time.Now().Format("2006-01-02")},
-		},
-		Table: "cards",
+// ReviewCard schedules the card for rating with its deck's preset, stores and
+// logs the result along with the time taken to answer and buries its
+// siblings until tomorrow when the preset asks for it. The writes happen in
+// one transaction; when it fails card is left unchanged. It reports whether
+// the review turned the card into a leech.
+func (cs *cardService) ReviewCard(ctx context.Context, card *models.Card, rating values.Difficulty, duration time.Duration) (bool, error) {
+	preset, err := deckPreset(ctx, cs.db, card.ParentDeckId)
+	if err != nil {
+		return false, err
+	}
+	before := *card
+	before.Tags = slices.Clone(card.Tags)
+	wasNew := card.IsNew()
+	params := presetParameters(preset)
+	params.Clock = cs.clock
+	fsrs.ReviewWith(params, rating, card)
+	var leech bool
+	err = cs.db.WithTx(ctx, func(tx *db.Tx) error {
+		if err := tx.UpdateInterval(ctx, card); err != nil {
+			return err
+		}
+		err := tx.AddReview(ctx, &models.Review{
+			CardID:     card.ID,
+			DeckID:     card.ParentDeckId,
+			Rating:     rating,
+			WasNew:     wasNew,
+			ReviewedAt: card.LastStudied,
+			Duration:   duration,
+		})
+		if err != nil {
+			return err
+		}
+		leech, err = checkLeech(ctx, tx.Database, preset, card)
+		if err != nil || card.NoteID == 0 {
+			return err
+		}
+		siblings, err := tx.GetCards(ctx, db.CardFilter{
+			Where: sq.And{sq.Eq{"NoteId": card.NoteID}, sq.NotEq{"ID": card.ID}},
+		})
+		if err != nil {
+			return err
+		}
+		var buried []int
+		for _, sibling := range siblings {
+			if buriesSibling(preset, sibling) {
+				buried = append(buried, sibling.ID)
+			}
+		}
+		return tx.BuryCards(ctx, buried, cs.clock.Tomorrow())
 	})
-	return int(counts), err
+	if err != nil {
+		*card = before
+		return false, err
+	}
+	return leech, nil
 }
-func (cs *cardService) isYesterdayStudied() (bool, error) {
-	value, err := cs.db.Count(db.CounterFilter{
-		Condition: sq.Eq{"date(LastStudied,'unixepoch')": time.Now().AddDate(0, 0, -1).Format("2006-01-02")},
-		Table:     "decks",
+
+// UndoReview puts a card back into the state before its last review, given
+// as before, and drops that review from the log. Siblings buried by the
+// review stay buried until tomorrow.
+func (cs *cardService) UndoReview(ctx context.Context, before *models.Card) error {
+	return cs.db.WithTx(ctx, func(tx *db.Tx) error {
+		if err := tx.RestoreSchedule(ctx, before); err != nil {
+			return err
+		}
+		return tx.DeleteLastReview(ctx, before.ID)
 	})
-	return value == 1, err
 }
-func (cs *cardService) GetStreak() (int, error) {
-	stats, err := cs.db.SelectStats()
-	if err != nil {
-		return 0, err
+
+// checkLeech tags the card as a leech once its lapses reach the preset's
+// threshold, suspending it too when the preset asks for it. It reports
+// whether the card just became a leech.
+func checkLeech(ctx context.Context, database *db.Database, preset *models.Preset, card *models.Card) (bool, error) {
+	if preset.LeechThreshold <= 0 || card.Lapses < preset.LeechThreshold || card.IsLeech() {
+		return false, nil
 	}
-	var lastTimeUpdated = stats.LastTimeUpdated
-	var streak = stats.DayStreak
-	if lastTimeUpdated.Format("2006-01-02") == time.Now().Format("2006-01-02") {
-		return stats.DayStreak, nil
+	tags := append(slices.Clone(card.Tags), models.LeechTag)
+	if err := database.SetTags(ctx, card.ID, tags); err != nil {
+		return false, err
 	}
-	isStudied, err := cs.isYesterdayStudied()
-	if err != nil {
-		return 0, err
+	card.Tags = tags
+	if preset.LeechAction == values.SuspendLeech {
+		if err := database.SetSuspended(ctx, []int{card.ID}, true); err != nil {
+			return false, err
+		}
+		card.Suspended = true
 	}
+	return true, nil
+}
 
-	if isStudied {
-		streak = streak + 1
-	} else {
-		streak = 0
+func (cs *cardService) SuspendCard(ctx context.Context, id int, suspended bool) error {
+	return cs.db.SetSuspended(ctx, []int{id}, suspended)
+}
+
+// BuryCard hides the card until tomorrow, or brings it back when buried is
+// false.
+func (cs *cardService) BuryCard(ctx context.Context, id int, buried bool) error {
+	var until time.Time
+	if buried {
+		until = cs.clock.Tomorrow()
 	}
-	err = cs.db.UpdateStats(map[string]any{"dayStreak": streak, "lastTimeUpdated": time.Now().Unix()})
-	return streak, err
+	return cs.db.BuryCards(ctx, []int{id}, until)
 }
 
-func (cs *cardService) CountDueCardsFromDeck(deckId int) (int, error) {
-	total, err := cs.db.Count(db.CounterFilter{
-		Condition: squirrel.And{
-			squirrel.Eq{"ParentDeckId": deckId},
-			squirrel.Or{
-				squirrel.Eq{"interval": nil},
-				squirrel.LtOrEq{"date(interval,'unixepoch')": time.Now().Format("2006-01-02")},
-			},
-		},
-		Table: "cards",
+func (cs *cardService) FlagCard(ctx context.Context, id int, flag values.Flag) error {
+	if flag < 0 || int(flag) >= len(values.FlagNames) {
+		return invalidInput("card", id, "unknown flag")
+	}
+	return cs.db.SetFlag(ctx, id, flag)
+}
+
+// SetTypeAnswer sets whether studying the card asks for a typed answer.
+func (cs *cardService) SetTypeAnswer(ctx context.Context, id int, typeAnswer bool) error {
+	return cs.db.SetTypeAnswer(ctx, []int{id}, typeAnswer)
+}
+
+// EditCard changes the card's sides and whether it asks for a typed answer,
+// in one transaction. Cards generated from a note write the change back to
+// the note so that siblings pick it up as well.
+func (cs *cardService) EditCard(ctx context.Context, id int, Front string, Back string, typeAnswer bool) error {
+	return cs.db.WithTx(ctx, func(tx *db.Tx) error {
+		if err := editCardSides(ctx, tx.Database, id, Front, Back); err != nil {
+			return err
+		}
+		return tx.SetTypeAnswer(ctx, []int{id}, typeAnswer)
 	})
-	return int(total), err
+}
+
+// editCardSides writes the sides of the card, through its note when it has
+// one.
+func editCardSides(ctx context.Context, database *db.Database, id int, Front string, Back string) error {
+	cards, err := database.GetCards(ctx, db.CardFilter{Where: sq.Eq{"ID": id}})
+	if err != nil {
+		return err
+	}
+	if len(cards) == 0 || cards[0].NoteID == 0 {
+		return database.EditCard(ctx, Front, Back, id)
+	}
+	card := cards[0]
+	note, err := getNote(ctx, database, card.NoteID)
+	if err != nil {
+		return err
+	}
+	noteType, err := database.GetNoteType(ctx, note.NoteTypeID)
+	if err != nil {
+		return err
+	}
+	for _, template := range noteType.Templates {
+		if template.Ord != card.Ord {
+			continue
+		}
+		frontField, backField, ok := sideFields(template)
+		if !ok || noteType.Kind == models.ClozeNote {
+			break
+		}
+		note.Fields[frontField] = Front
+		note.Fields[backField] = Back
+		if err := database.EditNote(ctx, note.ID, note.Fields); err != nil {
+			return err
+		}
+		_, err := syncNoteCards(ctx, database, noteType, note)
+		return err
+	}
+	return invalidInput("card", id, fmt.Sprintf("it is generated from a %s note; edit the note instead", noteType.Name))
+}
+
+// CountDueCards returns the number of cards the study queues hold.
+func (cs *cardService) CountDueCards(ctx context.Context) (int, error) {
+	queue, err := dueQueue(ctx, cs.db, cs.clock, nil)
+	return len(queue), err
+}
+
+// CountDueCardsFromDeck returns the number of cards the deck's study queue
+// holds.
+func (cs *cardService) CountDueCardsFromDeck(ctx context.Context, deckId int) (int, error) {
+	queue, err := dueQueue(ctx, cs.db, cs.clock, sq.Eq{"ParentDeckId": deckId})
+	return len(queue), err
 }
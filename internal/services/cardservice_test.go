@@ -0,0 +1,699 @@
+package services_test
+
+import (
+	"memoflash/internal/db"
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/models"
+	"memoflash/internal/services"
+	"memoflash/internal/values"
+	"slices"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+func TestCreateCard(t *testing.T) {
+	tests := []struct {
+		name       string
+		front      string
+		reversed   bool
+		typeAnswer bool
+		missing    bool
+		wantCards  int
+		wantErr    error
+	}{
+		{name: "plain", front: "hola", wantCards: 1},
+		{name: "reversed", front: "hola", reversed: true, wantCards: 2},
+		{name: "type answer", front: "hola", typeAnswer: true, wantCards: 1},
+		{name: "reversed type answer", front: "hola", reversed: true, typeAnswer: true, wantCards: 2},
+		{name: "blank front", front: " ", wantErr: services.ErrInvalidInput},
+		{name: "missing deck", front: "hola", missing: true, wantErr: services.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			deckId := dbtest.Deck("Spanish").Add(t, f.db).ID
+			if tt.missing {
+				deckId = 404
+			}
+			cards, err := f.cards.CreateCard(ctx, tt.front, "hello", deckId, tt.reversed, tt.typeAnswer)
+			checkError(t, err, tt.wantErr)
+			if len(cards) != tt.wantCards {
+				t.Errorf("CreateCard() = %d cards, want %d", len(cards), tt.wantCards)
+			}
+			if got := dbtest.Count(t, f.db, "cards", nil); got != tt.wantCards {
+				t.Errorf("cards = %d, want %d", got, tt.wantCards)
+			}
+			for _, card := range cards {
+				if !card.IsNew() || card.ParentDeckId != deckId {
+					t.Errorf("card %d: new = %v, deck = %d, want a new card of deck %d", card.ID, card.IsNew(), card.ParentDeckId, deckId)
+				}
+				if stored := dbtest.GetCard(t, f.db, card.ID); stored.TypeAnswer != tt.typeAnswer {
+					t.Errorf("card %d: TypeAnswer = %v, want %v", card.ID, stored.TypeAnswer, tt.typeAnswer)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteCard(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	card := dbtest.Card(deck.ID).Due(now).Add(t, f.db)
+	kept := dbtest.Card(deck.ID).Add(t, f.db)
+	dbtest.AddReview(t, f.db, card, values.Good, now)
+
+	checkError(t, f.cards.DeleteCard(ctx, card.ID), nil)
+	if got := dbtest.Count(t, f.db, "cards", nil); got != 1 {
+		t.Errorf("cards = %d, want 1", got)
+	}
+	if got := dbtest.Count(t, f.db, "reviews", nil); got != 0 {
+		t.Errorf("reviews = %d, want the card's reviews deleted with it", got)
+	}
+	checkError(t, f.cards.DeleteCard(ctx, card.ID), services.ErrNotFound)
+	dbtest.GetCard(t, f.db, kept.ID)
+}
+
+func TestDeleteSibling(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	reversed, err := f.cards.CreateCard(ctx, "hola", "hello", deck.ID, true, false)
+	checkError(t, err, nil)
+	cloze, err := f.notes.CreateClozeNote(ctx, "{{c1::Madrid}} is in {{c2::Spain}}", "", deck.ID)
+	checkError(t, err, nil)
+
+	checkError(t, f.cards.DeleteCard(ctx, reversed[1].ID), nil)
+	checkError(t, f.cards.EditCard(ctx, reversed[0].ID, "adiós", "goodbye", false), nil)
+	if got := dbtest.Count(t, f.db, "cards", sq.Eq{"NoteId": reversed[0].NoteID}); got != 1 {
+		t.Errorf("reversed note cards after EditCard = %d, want 1", got)
+	}
+	_, err = f.notes.EditNote(ctx, reversed[0].NoteID, map[string]string{"Front": "sí", "Back": "yes"})
+	checkError(t, err, nil)
+	if got := dbtest.Count(t, f.db, "cards", sq.Eq{"NoteId": reversed[0].NoteID}); got != 1 {
+		t.Errorf("reversed note cards after EditNote = %d, want 1", got)
+	}
+
+	checkError(t, f.cards.DeleteCard(ctx, cloze[0].ID), nil)
+	cards, err := f.notes.EditNote(ctx, cloze[0].NoteID, map[string]string{"Text": "{{c1::Lisbon}} is in {{c2::Portugal}} and {{c3::Europe}}"})
+	checkError(t, err, nil)
+	var ords []int
+	for _, card := range cards {
+		ords = append(ords, card.Ord)
+	}
+	if want := []int{1, 2}; !slices.Equal(ords, want) {
+		t.Errorf("cloze ordinals = %v, want %v", ords, want)
+	}
+}
+
+func TestCardsByDeck(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	empty := dbtest.Deck("Empty").Add(t, f.db)
+	dbtest.Card(deck.ID).Add(t, f.db)
+	dbtest.Card(deck.ID).Due(now).Add(t, f.db)
+
+	tests := []struct {
+		deckId int
+		want   int
+	}{
+		{deckId: deck.ID, want: 2},
+		{deckId: empty.ID, want: 0},
+		{deckId: 404, want: 0},
+	}
+	for _, tt := range tests {
+		cards, err := f.cards.GetCardsByDeck(ctx, tt.deckId)
+		checkError(t, err, nil)
+		if len(cards) != tt.want {
+			t.Errorf("GetCardsByDeck(%d) = %d cards, want %d", tt.deckId, len(cards), tt.want)
+		}
+		total, err := f.cards.GetTotalCardsInDeck(ctx, tt.deckId)
+		checkError(t, err, nil)
+		if total != tt.want {
+			t.Errorf("GetTotalCardsInDeck(%d) = %d, want %d", tt.deckId, total, tt.want)
+		}
+	}
+}
+
+func TestGetProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		new      int
+		reviewed int
+		want     int
+	}{
+		{name: "no cards", want: 0},
+		{name: "all new", new: 2, want: 0},
+		{name: "all reviewed", reviewed: 2, want: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			deck := dbtest.Deck("Spanish").Add(t, f.db)
+			for range tt.new {
+				dbtest.Card(deck.ID).Add(t, f.db)
+			}
+			for range tt.reviewed {
+				dbtest.Card(deck.ID).Due(now.AddDate(0, 0, 3)).Add(t, f.db)
+			}
+			got, err := f.cards.GetProgress(ctx)
+			checkError(t, err, nil)
+			if got != tt.want {
+				t.Errorf("GetProgress() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDueCards checks the due-date boundaries of every method listing or
+// counting due cards. A card is due from the start of the learning day it is
+// scheduled on.
+func TestDueCards(t *testing.T) {
+	tests := []struct {
+		name string
+		card func(deckId int) *dbtest.CardBuilder
+		due  bool
+	}{
+		{name: "new", card: dbtest.Card, due: true},
+		{name: "overdue", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).Due(now.AddDate(0, 0, -3))
+		}, due: true},
+		{name: "due earlier today", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).Due(day(0, rolloverHour, 0))
+		}, due: true},
+		{name: "due before midnight", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).Due(day(0, 23, 59))
+		}, due: true},
+		{name: "due before the rollover", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).Due(day(1, rolloverHour-1, 59))
+		}, due: true},
+		{name: "due at the rollover", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).Due(tomorrow)
+		}, due: false},
+		{name: "due next week", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).Due(now.AddDate(0, 0, 7))
+		}, due: false},
+		{name: "suspended", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).Due(now.AddDate(0, 0, -1)).Suspended()
+		}, due: false},
+		{name: "buried until tomorrow", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).BuriedUntil(tomorrow)
+		}, due: false},
+		{name: "buried until earlier today", card: func(deckId int) *dbtest.CardBuilder {
+			return dbtest.Card(deckId).BuriedUntil(day(0, rolloverHour, 0))
+		}, due: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			deck := dbtest.Deck("Spanish").Add(t, f.db)
+			other := dbtest.Deck("French").Add(t, f.db)
+			tt.card(deck.ID).Add(t, f.db)
+			// A card of another deck due now, counted only across decks.
+			dbtest.Card(other.ID).Due(now).Add(t, f.db)
+
+			want := 0
+			if tt.due {
+				want = 1
+			}
+			count, err := f.cards.CountDueCardsFromDeck(ctx, deck.ID)
+			checkError(t, err, nil)
+			if count != want {
+				t.Errorf("CountDueCardsFromDeck() = %d, want %d", count, want)
+			}
+			cards, err := f.cards.GetDueCardsFromDeck(ctx, deck.ID)
+			checkError(t, err, nil)
+			if len(cards) != want {
+				t.Errorf("GetDueCardsFromDeck() = %d cards, want %d", len(cards), want)
+			}
+			count, err = f.cards.CountDueCards(ctx)
+			checkError(t, err, nil)
+			if count != want+1 {
+				t.Errorf("CountDueCards() = %d, want %d", count, want+1)
+			}
+			cards, err = f.cards.GetAllDueCards(ctx, 0)
+			checkError(t, err, nil)
+			if len(cards) != want+1 {
+				t.Errorf("GetAllDueCards() = %d cards, want %d", len(cards), want+1)
+			}
+		})
+	}
+}
+
+func TestDueCardsFollowTheClock(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	dbtest.Card(deck.ID).Due(day(1, 12, 0)).Add(t, f.db)
+
+	steps := []struct {
+		at   time.Time
+		want int
+	}{
+		{at: day(0, 23, 59), want: 0},
+		{at: day(1, rolloverHour-1, 59), want: 0},
+		{at: tomorrow, want: 1},
+		{at: day(6, 0, 0), want: 1},
+	}
+	for _, step := range steps {
+		f.clock.Set(step.at)
+		count, err := f.cards.CountDueCardsFromDeck(ctx, deck.ID)
+		checkError(t, err, nil)
+		if count != step.want {
+			t.Errorf("at %v: CountDueCardsFromDeck() = %d, want %d", step.at, count, step.want)
+		}
+	}
+}
+
+func TestDueCardsDailyLimits(t *testing.T) {
+	f := newFixture(t)
+	preset := db.DefaultPreset()
+	preset.Name = "Small"
+	preset.NewPerDay = 2
+	preset.ReviewsPerDay = 1
+	presetId, err := f.presets.CreatePreset(ctx, preset)
+	checkError(t, err, nil)
+	deck := dbtest.Deck("Spanish").Preset(presetId).Add(t, f.db)
+	for range 3 {
+		dbtest.Card(deck.ID).Add(t, f.db)
+		dbtest.Card(deck.ID).Due(now.AddDate(0, 0, -1)).Add(t, f.db)
+	}
+	// One new card was already studied today, one yesterday.
+	studied := dbtest.Card(deck.ID).Due(now.AddDate(0, 0, 2)).Add(t, f.db)
+	studied.Interval = time.Time{}
+	dbtest.AddReview(t, f.db, studied, values.Good, day(0, 9, 0))
+	dbtest.AddReview(t, f.db, studied, values.Good, day(0, rolloverHour-1, 59))
+
+	cards, err := f.cards.GetDueCardsFromDeck(ctx, deck.ID)
+	checkError(t, err, nil)
+	var newCards, reviews int
+	for _, card := range cards {
+		if card.IsNew() {
+			newCards++
+		} else {
+			reviews++
+		}
+	}
+	if newCards != 1 || reviews != 1 {
+		t.Errorf("GetDueCardsFromDeck() = %d new, %d reviews, want 1, 1", newCards, reviews)
+	}
+}
+
+// TestAllDueCardsCeiling checks that the cross-deck limit of GetAllDueCards
+// applies on top of the limits of each deck's preset.
+func TestAllDueCardsCeiling(t *testing.T) {
+	f := newFixture(t)
+	preset := db.DefaultPreset()
+	preset.Name = "Small"
+	preset.NewPerDay = 2
+	presetId, err := f.presets.CreatePreset(ctx, preset)
+	checkError(t, err, nil)
+	limited := dbtest.Deck("Limited").Preset(presetId).Add(t, f.db)
+	other := dbtest.Deck("Other").Add(t, f.db)
+	for range 5 {
+		dbtest.Card(limited.ID).Add(t, f.db)
+	}
+	for range 3 {
+		dbtest.Card(other.ID).Add(t, f.db)
+	}
+
+	tests := []struct {
+		limit       int
+		want        int
+		wantLimited int
+	}{
+		{limit: 0, want: 5, wantLimited: 2},
+		{limit: 10, want: 5, wantLimited: 2},
+		{limit: 4, want: 4, wantLimited: 2},
+		{limit: 1, want: 1, wantLimited: 1},
+	}
+	for _, tt := range tests {
+		cards, err := f.cards.GetAllDueCards(ctx, tt.limit)
+		checkError(t, err, nil)
+		fromLimited := 0
+		for _, card := range cards {
+			if card.ParentDeckId == limited.ID {
+				fromLimited++
+			}
+		}
+		if len(cards) != tt.want || fromLimited > tt.wantLimited {
+			t.Errorf("GetAllDueCards(%d) = %d cards, %d of the limited deck, want %d, at most %d", tt.limit, len(cards), fromLimited, tt.want, tt.wantLimited)
+		}
+	}
+}
+
+// TestDueCountsMatchQueue checks that every due count agrees with the study
+// queue once limits and sibling burying leave cards out of it.
+func TestDueCountsMatchQueue(t *testing.T) {
+	f := newFixture(t)
+	preset := db.DefaultPreset()
+	preset.Name = "Small"
+	preset.NewPerDay = 2
+	presetId, err := f.presets.CreatePreset(ctx, preset)
+	checkError(t, err, nil)
+	limited := dbtest.Deck("Limited").Preset(presetId).Add(t, f.db)
+	for range 5 {
+		dbtest.Card(limited.ID).Add(t, f.db)
+	}
+	// The default preset buries the reversed sibling.
+	reversed := dbtest.Deck("Reversed").Add(t, f.db)
+	_, err = f.cards.CreateCard(ctx, "hola", "hello", reversed.ID, true, false)
+	checkError(t, err, nil)
+
+	want := map[int]int{limited.ID: 2, reversed.ID: 1}
+	for deckId, n := range want {
+		queue, err := f.cards.GetDueCardsFromDeck(ctx, deckId)
+		checkError(t, err, nil)
+		count, err := f.cards.CountDueCardsFromDeck(ctx, deckId)
+		checkError(t, err, nil)
+		if len(queue) != n || count != n {
+			t.Errorf("deck %d: queue, count = %d, %d, want %d", deckId, len(queue), count, n)
+		}
+	}
+	total, err := f.cards.CountDueCards(ctx)
+	checkError(t, err, nil)
+	if total != 3 {
+		t.Errorf("CountDueCards() = %d, want 3", total)
+	}
+	decks, err := f.decks.GetDecks(ctx)
+	checkError(t, err, nil)
+	for _, deck := range decks {
+		if deck.DueCards != want[deck.ID] {
+			t.Errorf("GetDecks() deck %d due = %d, want %d", deck.ID, deck.DueCards, want[deck.ID])
+		}
+	}
+}
+
+func TestEditCard(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	plain := dbtest.Card(deck.ID).Add(t, f.db)
+	reversed, err := f.cards.CreateCard(ctx, "hola", "hello", deck.ID, true, false)
+	checkError(t, err, nil)
+	cloze, err := f.notes.CreateClozeNote(ctx, "{{c1::Madrid}} is the capital", "", deck.ID)
+	checkError(t, err, nil)
+
+	t.Run("plain", func(t *testing.T) {
+		checkError(t, f.cards.EditCard(ctx, plain.ID, "new front", "new back", true), nil)
+		card := dbtest.GetCard(t, f.db, plain.ID)
+		if card.Front != "new front" || card.Back != "new back" || !card.TypeAnswer {
+			t.Errorf("sides = %q, %q, type answer = %v, want %q, %q, true", card.Front, card.Back, card.TypeAnswer, "new front", "new back")
+		}
+	})
+	t.Run("note with a sibling", func(t *testing.T) {
+		checkError(t, f.cards.EditCard(ctx, reversed[0].ID, "adiós", "goodbye", false), nil)
+		sibling := dbtest.GetCard(t, f.db, reversed[1].ID)
+		if sibling.Front != "goodbye" || sibling.Back != "adiós" {
+			t.Errorf("sibling sides = %q, %q, want %q, %q", sibling.Front, sibling.Back, "goodbye", "adiós")
+		}
+	})
+	t.Run("missing", func(t *testing.T) {
+		checkError(t, f.cards.EditCard(ctx, 404, "front", "back", false), services.ErrNotFound)
+	})
+	t.Run("cloze", func(t *testing.T) {
+		checkError(t, f.cards.EditCard(ctx, cloze[0].ID, "front", "back", true), services.ErrInvalidInput)
+		if dbtest.GetCard(t, f.db, cloze[0].ID).TypeAnswer {
+			t.Error("type answer was set by a rejected edit")
+		}
+	})
+}
+
+func TestReviewCard(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	card := dbtest.Card(deck.ID).Add(t, f.db)
+
+	leech, err := f.cards.ReviewCard(ctx, card, values.Good, 5*time.Second)
+	checkError(t, err, nil)
+	if leech {
+		t.Error("ReviewCard() reported a leech on a new card")
+	}
+	if !card.LastStudied.Equal(now) {
+		t.Errorf("LastStudied = %v, want the clock's time %v", card.LastStudied, now)
+	}
+	stored := dbtest.GetCard(t, f.db, card.ID)
+	if stored.IsNew() || !stored.Interval.Equal(card.Interval) {
+		t.Errorf("stored interval = %v, want %v", stored.Interval, card.Interval)
+	}
+	if got := dbtest.Count(t, f.db, "reviews", sq.Eq{"CardId": card.ID, "WasNew": true, "Rating": values.Good}); got != 1 {
+		t.Errorf("logged reviews = %d, want 1", got)
+	}
+}
+
+func TestReviewCardLeech(t *testing.T) {
+	tests := []struct {
+		name          string
+		lapses        int
+		rating        values.Difficulty
+		action        values.LeechAction
+		wantLeech     bool
+		wantSuspended bool
+	}{
+		{name: "below the threshold", lapses: 1, rating: values.Good, action: values.TagLeech},
+		{name: "reaches the threshold", lapses: 2, rating: values.Again, action: values.TagLeech, wantLeech: true},
+		{name: "suspends", lapses: 2, rating: values.Again, action: values.SuspendLeech, wantLeech: true, wantSuspended: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			preset := db.DefaultPreset()
+			preset.Name = "Strict"
+			preset.LeechThreshold = 3
+			preset.LeechAction = tt.action
+			presetId, err := f.presets.CreatePreset(ctx, preset)
+			checkError(t, err, nil)
+			deck := dbtest.Deck("Spanish").Preset(presetId).Add(t, f.db)
+			card := dbtest.Card(deck.ID).Due(now.AddDate(0, 0, -1)).Lapses(tt.lapses).Add(t, f.db)
+
+			leech, err := f.cards.ReviewCard(ctx, card, tt.rating, time.Second)
+			checkError(t, err, nil)
+			if leech != tt.wantLeech {
+				t.Errorf("ReviewCard() leech = %v, want %v", leech, tt.wantLeech)
+			}
+			stored := dbtest.GetCard(t, f.db, card.ID)
+			if stored.IsLeech() != tt.wantLeech || stored.Suspended != tt.wantSuspended {
+				t.Errorf("stored leech, suspended = %v, %v, want %v, %v", stored.IsLeech(), stored.Suspended, tt.wantLeech, tt.wantSuspended)
+			}
+		})
+	}
+}
+
+func TestReviewCardBuriesSiblings(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	cards, err := f.cards.CreateCard(ctx, "hola", "hello", deck.ID, true, false)
+	checkError(t, err, nil)
+
+	_, err = f.cards.ReviewCard(ctx, cards[0], values.Good, time.Second)
+	checkError(t, err, nil)
+	sibling := dbtest.GetCard(t, f.db, cards[1].ID)
+	if want := tomorrow; !sibling.BuriedUntil.Equal(want) {
+		t.Errorf("sibling buried until %v, want %v", sibling.BuriedUntil, want)
+	}
+	// The reviewed card is still in learning, so only it stays due today.
+	isSibling := func(card *models.Card) bool { return card.ID == cards[1].ID }
+	due, err := f.cards.GetDueCardsFromDeck(ctx, deck.ID)
+	checkError(t, err, nil)
+	if slices.ContainsFunc(due, isSibling) {
+		t.Error("sibling is still due today")
+	}
+	f.clock.Set(tomorrow)
+	due, err = f.cards.GetDueCardsFromDeck(ctx, deck.ID)
+	checkError(t, err, nil)
+	if !slices.ContainsFunc(due, isSibling) {
+		t.Error("sibling is not due the next day")
+	}
+}
+
+func TestUndoReview(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	card := dbtest.Card(deck.ID).Add(t, f.db)
+	before := *card
+
+	_, err := f.cards.ReviewCard(ctx, card, values.Easy, time.Second)
+	checkError(t, err, nil)
+	checkError(t, f.cards.UndoReview(ctx, &before), nil)
+	if stored := dbtest.GetCard(t, f.db, card.ID); !stored.IsNew() {
+		t.Errorf("stored interval = %v, want a new card", stored.Interval)
+	}
+	if got := dbtest.Count(t, f.db, "reviews", nil); got != 0 {
+		t.Errorf("reviews = %d, want 0", got)
+	}
+}
+
+func TestCardStateChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		apply func(f *fixture, id int) error
+		check func(card *models.Card) bool
+	}{
+		{
+			name:  "suspend",
+			apply: func(f *fixture, id int) error { return f.cards.SuspendCard(ctx, id, true) },
+			check: func(card *models.Card) bool { return card.Suspended },
+		},
+		{
+			name: "unsuspend",
+			apply: func(f *fixture, id int) error {
+				if err := f.cards.SuspendCard(ctx, id, true); err != nil {
+					return err
+				}
+				return f.cards.SuspendCard(ctx, id, false)
+			},
+			check: func(card *models.Card) bool { return !card.Suspended },
+		},
+		{
+			name:  "bury",
+			apply: func(f *fixture, id int) error { return f.cards.BuryCard(ctx, id, true) },
+			check: func(card *models.Card) bool { return card.BuriedUntil.Equal(tomorrow) },
+		},
+		{
+			name: "unbury",
+			apply: func(f *fixture, id int) error {
+				if err := f.cards.BuryCard(ctx, id, true); err != nil {
+					return err
+				}
+				return f.cards.BuryCard(ctx, id, false)
+			},
+			check: func(card *models.Card) bool { return card.BuriedUntil.IsZero() },
+		},
+		{
+			name:  "flag",
+			apply: func(f *fixture, id int) error { return f.cards.FlagCard(ctx, id, values.GreenFlag) },
+			check: func(card *models.Card) bool { return card.Flag == values.GreenFlag },
+		},
+		{
+			name:  "type answer",
+			apply: func(f *fixture, id int) error { return f.cards.SetTypeAnswer(ctx, id, true) },
+			check: func(card *models.Card) bool { return card.TypeAnswer },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			deck := dbtest.Deck("Spanish").Add(t, f.db)
+			card := dbtest.Card(deck.ID).Add(t, f.db)
+			checkError(t, tt.apply(f, card.ID), nil)
+			if stored := dbtest.GetCard(t, f.db, card.ID); !tt.check(stored) {
+				t.Errorf("stored card = %+v", stored)
+			}
+			checkError(t, tt.apply(f, 404), services.ErrNotFound)
+		})
+	}
+}
+
+func TestFlagCardUnknownFlag(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	card := dbtest.Card(deck.ID).Flag(values.RedFlag).Add(t, f.db)
+	for _, flag := range []values.Flag{-1, values.Flag(len(values.FlagNames))} {
+		checkError(t, f.cards.FlagCard(ctx, card.ID, flag), services.ErrInvalidInput)
+	}
+	if stored := dbtest.GetCard(t, f.db, card.ID); stored.Flag != values.RedFlag {
+		t.Errorf("flag = %v, want %v", stored.Flag, values.RedFlag)
+	}
+}
+
+func TestGetCustomStudyCards(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	other := dbtest.Deck("French").Add(t, f.db)
+	soon := dbtest.Card(deck.ID).Due(now.AddDate(0, 0, 2)).Add(t, f.db)
+	later := dbtest.Card(deck.ID).Due(now.AddDate(0, 0, 10)).Tags("verbs").Add(t, f.db)
+	fresh := dbtest.Card(deck.ID).Add(t, f.db)
+	dbtest.Card(deck.ID).Suspended().Add(t, f.db)
+	forgotten := dbtest.Card(deck.ID).Due(now).Add(t, f.db)
+	dbtest.AddReview(t, f.db, forgotten, values.Again, now.Add(-time.Hour))
+	dbtest.AddReview(t, f.db, soon, values.Again, now.AddDate(0, 0, -5))
+	tagged := dbtest.Card(other.ID).Tags("verbs").Add(t, f.db)
+	wildcards := dbtest.Card(other.ID).Tags("100%", "a_b").Add(t, f.db)
+	dbtest.Card(other.ID).Tags("axb", "1000").Add(t, f.db)
+
+	tests := []struct {
+		name    string
+		study   services.CustomStudy
+		want    []int
+		wantErr error
+	}{
+		{
+			name:  "review ahead",
+			study: services.CustomStudy{Mode: services.ReviewAhead, DeckID: deck.ID, Days: 3},
+			want:  []int{forgotten.ID, soon.ID},
+		},
+		{
+			name:  "extra new",
+			study: services.CustomStudy{Mode: services.ExtraNew, DeckID: deck.ID, Count: 5},
+			want:  []int{fresh.ID},
+		},
+		{
+			name:  "forgotten",
+			study: services.CustomStudy{Mode: services.Forgotten, DeckID: deck.ID, Days: 1},
+			want:  []int{forgotten.ID},
+		},
+		{
+			name:  "cram by tag",
+			study: services.CustomStudy{Mode: services.RandomCram, Tag: "verbs", Count: 5},
+			want:  []int{later.ID, tagged.ID},
+		},
+		{
+			name:  "cram by tag with an underscore",
+			study: services.CustomStudy{Mode: services.RandomCram, Tag: "a_b", Count: 5},
+			want:  []int{wildcards.ID},
+		},
+		{
+			name:  "cram by tag with a percent sign",
+			study: services.CustomStudy{Mode: services.RandomCram, Tag: "100%", Count: 5},
+			want:  []int{wildcards.ID},
+		},
+		{
+			name:  "cram by a bare wildcard",
+			study: services.CustomStudy{Mode: services.RandomCram, Tag: "%", Count: 5},
+		},
+		{
+			name:    "unknown mode",
+			study:   services.CustomStudy{Mode: 42, DeckID: deck.ID},
+			wantErr: services.ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards, err := f.cards.GetCustomStudyCards(ctx, tt.study)
+			checkError(t, err, tt.wantErr)
+			var got []int
+			for _, card := range cards {
+				got = append(got, card.ID)
+			}
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetCustomStudyCards() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetQuiz(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	for _, back := range []string{"one", "two", "three", "four"} {
+		dbtest.Card(deck.ID).Sides("front", back).Add(t, f.db)
+	}
+	dbtest.Card(deck.ID).Sides("later", "five").Due(now.AddDate(0, 0, 3)).Add(t, f.db)
+	if _, err := f.notes.CreateClozeNote(ctx, "{{c1::Madrid}} is the capital", "", deck.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	questions, err := f.cards.GetQuiz(ctx, deck.ID)
+	checkError(t, err, nil)
+	if len(questions) != 4 {
+		t.Fatalf("GetQuiz() = %d questions, want one per due card that isn't a cloze", len(questions))
+	}
+	for _, question := range questions {
+		if len(question.Options) != services.QuizOptions {
+			t.Errorf("card %d: %d options, want %d", question.Card.ID, len(question.Options), services.QuizOptions)
+		}
+		if got := question.Options[question.Answer]; got != services.QuizOption(question.Card.Back) {
+			t.Errorf("card %d: answer = %q, want %q", question.Card.ID, got, question.Card.Back)
+		}
+	}
+}
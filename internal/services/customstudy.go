@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// CustomStudyMode selects the cards of a custom study session.
+type CustomStudyMode int
+
+const (
+	// ReviewAhead studies cards due within the next Days days.
+	ReviewAhead CustomStudyMode = iota
+	// ExtraNew studies Count new cards beyond the daily limit.
+	ExtraNew
+	// Forgotten studies cards rated Again in the last Days days.
+	Forgotten
+	// RandomCram studies a random sample of Count cards from the deck, or
+	// from every card carrying Tag.
+	RandomCram
+)
+
+var CustomStudyModeNames = []string{
+	"Review ahead",
+	"Study extra new cards",
+	"Review forgotten cards",
+	"Cram a random sample",
+}
+
+// CustomStudy describes a temporary study queue built outside the daily
+// limits.
+type CustomStudy struct {
+	Mode   CustomStudyMode
+	DeckID int
+	// Tag, when set, replaces the deck as the source of a RandomCram session.
+	Tag   string
+	Days  int
+	Count int
+}
+
+// GetCustomStudyCards builds the queue of a custom study session. Suspended
+// cards are never included.
+func (cs *cardService) GetCustomStudyCards(ctx context.Context, study CustomStudy) ([]*models.Card, error) {
+	where := sq.And{sq.Eq{"Suspended": false}}
+	if study.Mode != RandomCram || study.Tag == "" {
+		where = append(where, sq.Eq{"ParentDeckId": study.DeckID})
+	}
+	filter := db.CardFilter{Where: where, Order: "Interval ASC"}
+	switch study.Mode {
+	case ReviewAhead:
+		filter.Where = append(where,
+			sq.NotEq{"Interval": nil},
+			sq.LtOrEq{"Interval": cs.clock.Now().AddDate(0, 0, study.Days).Unix()},
+		)
+	case ExtraNew:
+		filter.Where = append(where, sq.Eq{"Interval": nil})
+		filter.Order = "ID ASC"
+		filter.Limit = uint64(study.Count)
+	case Forgotten:
+		since := cs.clock.Now().AddDate(0, 0, -study.Days).Unix()
+		filter.Where = append(where,
+			sq.Expr("ID IN (SELECT CardId FROM reviews WHERE Rating = ? AND ReviewedAt >= ?)", values.Again, since),
+		)
+	case RandomCram:
+		if study.Tag != "" {
+			filter.Where = append(where, sq.Expr(`' ' || Tags || ' ' LIKE ? ESCAPE '\'`, "% "+escapeLike(study.Tag)+" %"))
+		}
+		filter.Order = "RANDOM()"
+		filter.Limit = uint64(study.Count)
+	default:
+		return nil, invalidInput("custom study", 0, fmt.Sprintf("unknown mode %d", study.Mode))
+	}
+	return cs.db.GetCards(ctx, filter)
+}
+
+// likeEscaper escapes the LIKE wildcards, for patterns using ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes s match itself literally in a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -1,100 +1,104 @@
 package services
 
 import (
+	"context"
 	"memoflash/internal/db"
 	"memoflash/internal/models"
+	"memoflash/pkg/clock"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
-
-	"time"
 )
 
 type DeckService interface {
-	DeleteDeck(id int) error
-	CreateDeck(name string, description string, CategoryColorIndex int) (int, error)
-	EditDeck(id int, name string, description string, CategoryColorIndex int) error
-	GetDecks() ([]*models.Deck, error)
-	GetRecentlyStudiedDecks() ([]*models.Deck, error)
-	GetCardsFromDeck(deckId int) ([]*models.Card, error)
-	UpdateInterval(id int, interval time.Time, Difficulty float64, Stability float64) error
-	UpdateReadTime(id int) error
+	DeleteDeck(ctx context.Context, id int) error
+	CreateDeck(ctx context.Context, name string, description string, CategoryColorIndex int) (int, error)
+	EditDeck(ctx context.Context, id int, name string, description string, CategoryColorIndex int) error
+	GetDecks(ctx context.Context) ([]*models.Deck, error)
+	GetRecentlyStudiedDecks(ctx context.Context) ([]*models.Deck, error)
+	GetCardsFromDeck(ctx context.Context, deckId int) ([]*models.Card, error)
+	UpdateReadTime(ctx context.Context, id int) error
 }
 
 type deckService struct {
-	db *db.Database
+	db    *db.Database
+	clock *clock.Calendar
 }
 
-func NewDeckService(db *db.Database) DeckService {
-	return &deckService{db: db}
+func NewDeckService(db *db.Database, clock *clock.Calendar) DeckService {
+	return &deckService{db: db, clock: clock}
 }
 
-func (ds *deckService) DeleteDeck(id int) error {
-	return ds.db.DeleteDeck(db.DeckFilter{
+func (ds *deckService) DeleteDeck(ctx context.Context, id int) error {
+	return ds.db.DeleteDeck(ctx, db.DeckFilter{
 		Where: sq.Eq{"ID": id},
 	})
 }
-func (ds *deckService) CreateDeck(name string, description string, CategoryColorIndex int) (int, error) {
-	return ds.db.CreateDeck(name, description, CategoryColorIndex)
+func (ds *deckService) CreateDeck(ctx context.Context, name string, description string, CategoryColorIndex int) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		return 0, invalidInput("deck", 0, "the title is empty")
+	}
+	return ds.db.CreateDeck(ctx, name, description, CategoryColorIndex, ds.clock.Now())
 }
 
-func (ds *deckService) GetRecentlyStudiedDecks() ([]*models.Deck, error) {
-	return ds.db.GetDecks(db.DeckFilter{
+func (ds *deckService) GetRecentlyStudiedDecks(ctx context.Context) ([]*models.Deck, error) {
+	decks, err := ds.db.GetDecks(ctx, db.DeckFilter{
 		Limit: 3,
 		Where: sq.NotEq{
 			"Interval": nil,
 		},
 		OrderBy: "decks.LastStudied DESC",
 	})
+	if err != nil {
+		return nil, err
+	}
+	return decks, ds.countDue(ctx, decks)
 }
-func (ds *deckService) GetDecks() ([]*models.Deck, error) {
-	d, err := ds.db.GetDecks(db.DeckFilter{
+func (ds *deckService) GetDecks(ctx context.Context) ([]*models.Deck, error) {
+	decks, err := ds.db.GetDecks(ctx, db.DeckFilter{
 		OrderBy: "decks.LastStudied ASC",
 	})
-	return d, err
-}
-func (ds *deckService) UpdateReadTime(id int) error {
-	return ds.db.UpdateReadTime(id)
-}
-
-func (ds *deckService) EditDeck(id int, name string, description string, CategoryColorIndex int) error {
-	return ds.db.EditDeck(id, name, description, CategoryColorIndex)
+	if err != nil {
+		return nil, err
+	}
+	return decks, ds.countDue(ctx, decks)
 }
-func (ds *deckService) GetCardsFromDeck(deckId int) ([]*models.Card, error) {
-	return ds.db.GetCards(db.CardFilter{
-		Where: sq.Eq{"ParentDeckId": deckId},
-	})
 
-}
-func (ds *deckService) UpdateInterval(id int, interval time.Time, Difficulty float64, Stability float64) error {
-	return ds.db.UpdateInterval(interval, Difficulty, Stability, id)
-}
-func (cs *deckService) isYesterdayStudied() (bool, error) {
-	value, err := cs.db.Count(db.CounterFilter{
-		Condition: sq.Eq{"date(LastStudied,'unixepoch')": time.Now().AddDate(0, 0, -1).Format("2006-01-02")},
-		Table:     "decks",
-	})
-	return value == 1, err
-}
-func (cs *deckService) GetStreak() (int, error) {
-	stats, err := cs.db.SelectStats()
-	if err != nil {
-		return 0, err
+// countDue sets the DueCards of decks to the size of their study queues.
+func (ds *deckService) countDue(ctx context.Context, decks []*models.Deck) error {
+	if len(decks) == 0 {
+		return nil
 	}
-	var lastTimeUpdated = stats.LastTimeUpdated
-	var streak = stats.DayStreak
-	if lastTimeUpdated.Format("2006-01-02") == time.Now().Format("2006-01-02") {
-		return stats.DayStreak, nil
+	ids := make([]int, len(decks))
+	for i, deck := range decks {
+		ids[i] = deck.ID
 	}
-	isStudied, err := cs.isYesterdayStudied()
+	queue, err := dueQueue(ctx, ds.db, ds.clock, sq.Eq{"ParentDeckId": ids})
 	if err != nil {
-		return 0, err
+		return err
+	}
+	due := make(map[int]int, len(decks))
+	for _, card := range queue {
+		due[card.ParentDeckId]++
+	}
+	for _, deck := range decks {
+		deck.DueCards = due[deck.ID]
 	}
+	return nil
+}
+func (ds *deckService) UpdateReadTime(ctx context.Context, id int) error {
+	return ds.db.UpdateReadTime(ctx, id, ds.clock.Now())
+}
 
-	if isStudied {
-		streak = streak + 1
-	} else {
-		streak = 0
+func (ds *deckService) EditDeck(ctx context.Context, id int, name string, description string, CategoryColorIndex int) error {
+	if strings.TrimSpace(name) == "" {
+		return invalidInput("deck", id, "the title is empty")
 	}
-	err = cs.db.UpdateStats(map[string]any{"dayStreak": streak, "lastTimeUpdated": time.Now().Unix()})
-	return streak, err
+	return ds.db.EditDeck(ctx, id, name, description, CategoryColorIndex)
+}
+func (ds *deckService) GetCardsFromDeck(ctx context.Context, deckId int) ([]*models.Card, error) {
+	return ds.db.GetCards(ctx, db.CardFilter{
+		Where: sq.Eq{"ParentDeckId": deckId},
+	})
+
 }
@@ -0,0 +1,162 @@
+package services_test
+
+import (
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/services"
+	"memoflash/internal/values"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+func TestCreateDeck(t *testing.T) {
+	tests := []struct {
+		name    string
+		title   string
+		wantErr error
+	}{
+		{name: "valid", title: "Spanish"},
+		{name: "blank title", title: "  ", wantErr: services.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			id, err := f.decks.CreateDeck(ctx, tt.title, "Vocabulary", 2)
+			checkError(t, err, tt.wantErr)
+			want := 0
+			if tt.wantErr == nil {
+				want = 1
+			}
+			if got := dbtest.Count(t, f.db, "decks", sq.Eq{"ID": id, "CreatedAt": now.Unix()}); got != want {
+				t.Errorf("decks created at the clock's time = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestEditDeck(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      func(deckId int) int
+		title   string
+		wantErr error
+	}{
+		{name: "valid", id: func(deckId int) int { return deckId }, title: "French"},
+		{name: "missing deck", id: func(int) int { return 404 }, title: "French", wantErr: services.ErrNotFound},
+		{name: "blank title", id: func(deckId int) int { return deckId }, wantErr: services.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			deck := dbtest.Deck("Spanish").Add(t, f.db)
+			err := f.decks.EditDeck(ctx, tt.id(deck.ID), tt.title, "Verbs", 1)
+			checkError(t, err, tt.wantErr)
+			want := "Spanish"
+			if tt.wantErr == nil {
+				want = tt.title
+			}
+			if got := dbtest.Count(t, f.db, "decks", sq.Eq{"Title": want}); got != 1 {
+				t.Errorf("decks titled %q = %d, want 1", want, got)
+			}
+		})
+	}
+}
+
+func TestDeleteDeck(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	card := dbtest.Card(deck.ID).Due(now).Add(t, f.db)
+	dbtest.AddReview(t, f.db, card, values.Good, now)
+	if _, err := f.cards.CreateCard(ctx, "hola", "hello", deck.ID, true, false); err != nil {
+		t.Fatal(err)
+	}
+	other := dbtest.Deck("French").Add(t, f.db)
+	dbtest.Card(other.ID).Add(t, f.db)
+
+	checkError(t, f.decks.DeleteDeck(ctx, deck.ID), nil)
+	for table, want := range map[string]int{"decks": 1, "cards": 1, "notes": 0, "reviews": 0} {
+		if got := dbtest.Count(t, f.db, table, nil); got != want {
+			t.Errorf("%s = %d after delete, want %d", table, got, want)
+		}
+	}
+	checkError(t, f.decks.DeleteDeck(ctx, deck.ID), services.ErrNotFound)
+}
+
+func TestGetDecks(t *testing.T) {
+	f := newFixture(t)
+	older := dbtest.Deck("Older").StudiedAt(now.AddDate(0, 0, -2)).Add(t, f.db)
+	newer := dbtest.Deck("Newer").StudiedAt(now.AddDate(0, 0, -1)).Add(t, f.db)
+	dbtest.Card(older.ID).Add(t, f.db)
+	dbtest.Card(older.ID).Due(day(1, rolloverHour-1, 0)).Add(t, f.db)
+	dbtest.Card(older.ID).Suspended().Add(t, f.db)
+	dbtest.Card(older.ID).Due(tomorrow).Add(t, f.db)
+
+	decks, err := f.decks.GetDecks(ctx)
+	checkError(t, err, nil)
+	if len(decks) != 2 {
+		t.Fatalf("GetDecks() = %d decks, want 2", len(decks))
+	}
+	if decks[0].ID != older.ID || decks[1].ID != newer.ID {
+		t.Errorf("GetDecks() = [%d %d], want the least recently studied first [%d %d]", decks[0].ID, decks[1].ID, older.ID, newer.ID)
+	}
+	if decks[0].TotalCards != 4 || decks[0].DueCards != 2 {
+		t.Errorf("total, due = %d, %d, want 4, 2", decks[0].TotalCards, decks[0].DueCards)
+	}
+	if decks[1].TotalCards != 0 || decks[1].DueCards != 0 {
+		t.Errorf("empty deck total, due = %d, %d, want 0, 0", decks[1].TotalCards, decks[1].DueCards)
+	}
+}
+
+func TestGetRecentlyStudiedDecks(t *testing.T) {
+	f := newFixture(t)
+	var want []int
+	for days := 1; days <= 4; days++ {
+		deck := dbtest.Deck("Studied").StudiedAt(now.AddDate(0, 0, -days)).Add(t, f.db)
+		dbtest.Card(deck.ID).Due(now).Add(t, f.db)
+		want = append(want, deck.ID)
+	}
+	// Decks whose cards were never reviewed are left out.
+	unstudied := dbtest.Deck("Unstudied").StudiedAt(now).Add(t, f.db)
+	dbtest.Card(unstudied.ID).Add(t, f.db)
+
+	decks, err := f.decks.GetRecentlyStudiedDecks(ctx)
+	checkError(t, err, nil)
+	var got []int
+	for _, deck := range decks {
+		got = append(got, deck.ID)
+	}
+	want = want[:3]
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("GetRecentlyStudiedDecks() = %v, want %v", got, want)
+	}
+}
+
+func TestGetCardsFromDeck(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	other := dbtest.Deck("French").Add(t, f.db)
+	dbtest.Card(deck.ID).Add(t, f.db)
+	dbtest.Card(deck.ID).Suspended().Add(t, f.db)
+	dbtest.Card(other.ID).Add(t, f.db)
+
+	cards, err := f.decks.GetCardsFromDeck(ctx, deck.ID)
+	checkError(t, err, nil)
+	if len(cards) != 2 {
+		t.Errorf("GetCardsFromDeck() = %d cards, want 2", len(cards))
+	}
+	for _, card := range cards {
+		if card.ParentDeckId != deck.ID {
+			t.Errorf("card %d is in deck %d, want %d", card.ID, card.ParentDeckId, deck.ID)
+		}
+	}
+}
+
+func TestUpdateReadTime(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	checkError(t, f.decks.UpdateReadTime(ctx, deck.ID), nil)
+	if got := dbtest.Count(t, f.db, "decks", sq.Eq{"LastStudied": now.Unix()}); got != 1 {
+		t.Errorf("decks studied at %v = %d, want 1", now, got)
+	}
+	checkError(t, f.decks.UpdateReadTime(ctx, 404), services.ErrNotFound)
+}
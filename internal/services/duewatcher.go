@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DueWatcher polls the number of due cards and tells its listeners when it
+// changes. Poll counts once; Run polls on a timer.
+type DueWatcher struct {
+	cards CardService
+
+	mu        sync.Mutex
+	due       int
+	polled    bool
+	listeners []func(due int)
+}
+
+// NewDueWatcher returns a DueWatcher counting the due cards with cards.
+func NewDueWatcher(cards CardService) *DueWatcher {
+	return &DueWatcher{cards: cards}
+}
+
+// OnChange adds f to the functions called with the number of due cards
+// after a poll finds it changed. The first poll always counts as a change.
+// They are called from the polling goroutine.
+func (w *DueWatcher) OnChange(f func(due int)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, f)
+}
+
+// Due returns the number of due cards found by the last poll, and whether
+// there was one.
+func (w *DueWatcher) Due() (int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.due, w.polled
+}
+
+// Poll counts the due cards, calling the OnChange functions when the count
+// differs from the last poll's.
+func (w *DueWatcher) Poll(ctx context.Context) (int, error) {
+	due, err := w.cards.CountDueCards(ctx)
+	if err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	changed := !w.polled || due != w.due
+	w.due, w.polled = due, true
+	listeners := w.listeners
+	w.mu.Unlock()
+	if changed {
+		for _, f := range listeners {
+			f(due)
+		}
+	}
+	return due, nil
+}
+
+// Run polls every interval until ctx is done. Errors are passed to onError,
+// which may be nil.
+func (w *DueWatcher) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Poll(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
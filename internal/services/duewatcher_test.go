@@ -0,0 +1,74 @@
+package services_test
+
+import (
+	"context"
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/services"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDueWatcher(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	dbtest.Card(deck.ID).Add(t, f.db)
+	dbtest.Card(deck.ID).Due(tomorrow).Add(t, f.db)
+
+	watcher := services.NewDueWatcher(f.cards)
+	if _, polled := watcher.Due(); polled {
+		t.Fatal("Due() reports a poll before the first one")
+	}
+	var changes []int
+	watcher.OnChange(func(due int) { changes = append(changes, due) })
+
+	poll := func(want int) {
+		t.Helper()
+		due, err := watcher.Poll(ctx)
+		checkError(t, err, nil)
+		if due != want {
+			t.Errorf("Poll() = %d, want %d", due, want)
+		}
+		if got, _ := watcher.Due(); got != want {
+			t.Errorf("Due() = %d, want %d", got, want)
+		}
+	}
+	poll(1)
+	poll(1)
+	dbtest.Card(deck.ID).Add(t, f.db)
+	poll(2)
+	// The card scheduled for tomorrow becomes due when the day rolls over.
+	f.clock.Set(tomorrow)
+	poll(3)
+	poll(3)
+
+	if want := []int{1, 2, 3}; !slices.Equal(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+}
+
+func TestDueWatcherRun(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	dbtest.Card(deck.ID).Add(t, f.db)
+
+	watcher := services.NewDueWatcher(f.cards)
+	changed := make(chan int, 1)
+	watcher.OnChange(func(due int) { changed <- due })
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx, time.Millisecond, nil)
+		close(done)
+	}()
+
+	if due := <-changed; due != 1 {
+		t.Errorf("first change = %d, want 1", due)
+	}
+	dbtest.Card(deck.ID).Add(t, f.db)
+	if due := <-changed; due != 2 {
+		t.Errorf("second change = %d, want 2", due)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"memoflash/pkg/clock"
+)
+
+// GoalProgress is the progress of one learning day towards its goal.
+type GoalProgress struct {
+	models.DailyProgress
+	// Goal is the goal in effect on the day, or nil when none was.
+	Goal *models.Goal
+}
+
+// Done returns the progress in the unit of the goal: answers, or whole
+// minutes spent answering.
+func (p *GoalProgress) Done() int {
+	if p.Goal != nil && p.Goal.Kind == values.MinuteGoal {
+		return int(p.Duration.Minutes())
+	}
+	return p.Reviews
+}
+
+// Fraction returns the share of the goal done, from 0 to 1. It is 0 without
+// a goal.
+func (p *GoalProgress) Fraction() float32 {
+	if p.Goal == nil || p.Goal.Target <= 0 {
+		return 0
+	}
+	return min(float32(p.Done())/float32(p.Goal.Target), 1)
+}
+
+// Met reports whether the day had a goal and reached it.
+func (p *GoalProgress) Met() bool {
+	return p.Goal != nil && p.Goal.Target > 0 && p.Done() >= p.Goal.Target
+}
+
+type GoalService interface {
+	GetGoal(ctx context.Context) (*models.Goal, error)
+	SetGoal(ctx context.Context, kind values.GoalKind, target int) error
+	GetGoalProgress(ctx context.Context, days int) ([]*GoalProgress, error)
+}
+
+type goalService struct {
+	db    *db.Database
+	clock *clock.Calendar
+}
+
+func NewGoalService(db *db.Database, clock *clock.Calendar) GoalService {
+	return &goalService{db: db, clock: clock}
+}
+
+// GetGoal returns today's goal, or nil when there is none.
+func (gs *goalService) GetGoal(ctx context.Context) (*models.Goal, error) {
+	progress, err := gs.GetGoalProgress(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return progress[0].Goal, nil
+}
+
+// SetGoal makes target of kind the daily goal from today on. A zero target
+// removes the goal; earlier days keep the goal they had.
+func (gs *goalService) SetGoal(ctx context.Context, kind values.GoalKind, target int) error {
+	if target < 0 {
+		return invalidInput("goal", 0, "the target is negative")
+	}
+	if kind < 0 || int(kind) >= len(values.GoalKindNames) {
+		return invalidInput("goal", 0, "unknown kind")
+	}
+	_, err := gs.db.AddGoal(ctx, &models.Goal{Kind: kind, Target: target, StartsAt: gs.clock.Today()})
+	return err
+}
+
+// GetGoalProgress returns the progress of each of the last days learning
+// days, today included, oldest first. Progress is worked out from the review
+// log, so an undone answer no longer counts.
+func (gs *goalService) GetGoalProgress(ctx context.Context, days int) ([]*GoalProgress, error) {
+	days = max(days, 1)
+	first := gs.clock.Today().AddDate(0, 0, 1-days)
+	daily := make([]*models.DailyProgress, days)
+	for i := range daily {
+		daily[i] = &models.DailyProgress{Day: gs.clock.DayStart(first.AddDate(0, 0, i))}
+	}
+	buckets, err := gs.db.CountReviewsByMinute(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		if i := daysBetween(first, gs.clock.DayStart(bucket.At)); i >= 0 && i < days {
+			daily[i].Reviews += bucket.Reviews
+			daily[i].Duration += bucket.Duration
+		}
+	}
+	goals, err := gs.db.GetGoals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	progress := make([]*GoalProgress, days)
+	next := 0
+	var goal *models.Goal
+	for i, day := range daily {
+		// The goal of a day is the last one set before the day ended.
+		for next < len(goals) && goals[next].StartsAt.Before(gs.clock.NextDayStart(day.Day)) {
+			goal = goals[next]
+			next++
+		}
+		progress[i] = &GoalProgress{DailyProgress: *day}
+		if goal != nil && goal.Target > 0 {
+			progress[i].Goal = goal
+		}
+	}
+	return progress, nil
+}
@@ -0,0 +1,156 @@
+package services_test
+
+import (
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/services"
+	"memoflash/internal/values"
+	"testing"
+	"time"
+)
+
+func TestSetGoal(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    values.GoalKind
+		target  int
+		want    bool
+		wantErr error
+	}{
+		{name: "reviews", kind: values.ReviewGoal, target: 50, want: true},
+		{name: "minutes", kind: values.MinuteGoal, target: 15, want: true},
+		{name: "no goal", kind: values.ReviewGoal},
+		{name: "negative target", kind: values.ReviewGoal, target: -1, wantErr: services.ErrInvalidInput},
+		{name: "unknown kind", kind: 42, target: 10, wantErr: services.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			checkError(t, f.goals.SetGoal(ctx, tt.kind, tt.target), tt.wantErr)
+			goal, err := f.goals.GetGoal(ctx)
+			checkError(t, err, nil)
+			if (goal != nil) != tt.want {
+				t.Fatalf("GetGoal() = %+v, want a goal: %v", goal, tt.want)
+			}
+			if goal != nil && (goal.Kind != tt.kind || goal.Target != tt.target) {
+				t.Errorf("GetGoal() = %v %d, want %v %d", goal.Kind, goal.Target, tt.kind, tt.target)
+			}
+		})
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	tests := []struct {
+		name         string
+		kind         values.GoalKind
+		target       int
+		durations    []time.Duration
+		undo         bool
+		wantDone     int
+		wantFraction float32
+		wantMet      bool
+	}{
+		{
+			name: "reviews", kind: values.ReviewGoal, target: 4,
+			durations: []time.Duration{time.Second, time.Second},
+			wantDone:  2, wantFraction: 0.5,
+		},
+		{
+			name: "reviews met", kind: values.ReviewGoal, target: 2,
+			durations: []time.Duration{time.Second, time.Second, time.Second},
+			wantDone:  3, wantFraction: 1, wantMet: true,
+		},
+		{
+			name: "minutes", kind: values.MinuteGoal, target: 2,
+			durations: []time.Duration{50 * time.Second, 40 * time.Second},
+			wantDone:  1, wantFraction: 0.5,
+		},
+		{
+			name: "undone review", kind: values.MinuteGoal, target: 1,
+			durations: []time.Duration{time.Minute, 30 * time.Second},
+			undo:      true,
+			wantDone:  0, wantFraction: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			checkError(t, f.goals.SetGoal(ctx, tt.kind, tt.target), nil)
+			deck := dbtest.Deck("Spanish").Add(t, f.db)
+			for _, duration := range tt.durations {
+				card := dbtest.Card(deck.ID).Add(t, f.db)
+				before := *card
+				_, err := f.cards.ReviewCard(ctx, card, values.Good, duration)
+				checkError(t, err, nil)
+				if tt.undo {
+					checkError(t, f.cards.UndoReview(ctx, &before), nil)
+					tt.undo = false
+				}
+			}
+
+			progress, err := f.goals.GetGoalProgress(ctx, 1)
+			checkError(t, err, nil)
+			today := progress[0]
+			if !today.Day.Equal(f.day.Today()) {
+				t.Errorf("day = %v, want %v", today.Day, f.day.Today())
+			}
+			if today.Done() != tt.wantDone || today.Fraction() != tt.wantFraction || today.Met() != tt.wantMet {
+				t.Errorf("done, fraction, met = %d, %v, %v, want %d, %v, %v",
+					today.Done(), today.Fraction(), today.Met(), tt.wantDone, tt.wantFraction, tt.wantMet)
+			}
+		})
+	}
+}
+
+func TestGoalHistory(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	review := func(at time.Time) {
+		t.Helper()
+		f.clock.Set(at)
+		card := dbtest.Card(deck.ID).Add(t, f.db)
+		if _, err := f.cards.ReviewCard(ctx, card, values.Good, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkError(t, f.goals.SetGoal(ctx, values.ReviewGoal, 2), nil)
+	review(day(0, 15, 0))
+	// Past midnight, before the rollover: still the first day.
+	review(day(1, rolloverHour-1, 0))
+	f.clock.Set(day(1, 9, 0))
+	checkError(t, f.goals.SetGoal(ctx, values.MinuteGoal, 5), nil)
+	review(day(1, 10, 0))
+	f.clock.Set(day(3, 9, 0))
+	checkError(t, f.goals.SetGoal(ctx, values.ReviewGoal, 0), nil)
+	review(day(3, 10, 0))
+
+	history, err := f.goals.GetGoalProgress(ctx, 5)
+	checkError(t, err, nil)
+	want := []struct {
+		reviews int
+		goal    bool
+		kind    values.GoalKind
+		met     bool
+	}{
+		{},
+		{reviews: 2, goal: true, kind: values.ReviewGoal, met: true},
+		{reviews: 1, goal: true, kind: values.MinuteGoal},
+		{goal: true, kind: values.MinuteGoal},
+		{reviews: 1},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("GetGoalProgress() = %d days, want %d", len(history), len(want))
+	}
+	for i, w := range want {
+		got := history[i]
+		if wantDay := day(i-1, rolloverHour, 0); !got.Day.Equal(wantDay) {
+			t.Errorf("day %d = %v, want %v", i, got.Day, wantDay)
+		}
+		if got.Reviews != w.reviews || (got.Goal != nil) != w.goal || got.Met() != w.met {
+			t.Errorf("day %d: reviews = %d, goal = %+v, met = %v, want %d, %v, %v", i, got.Reviews, got.Goal, got.Met(), w.reviews, w.goal, w.met)
+		}
+		if got.Goal != nil && got.Goal.Kind != w.kind {
+			t.Errorf("day %d: goal kind = %v, want %v", i, got.Goal.Kind, w.kind)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"maps"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/pkg/cardtemplate"
+	"memoflash/pkg/clock"
+	"memoflash/pkg/cloze"
+	"slices"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type NoteService interface {
+	GetNoteTypes(ctx context.Context) ([]*models.NoteType, error)
+	GetNote(ctx context.Context, id int) (*models.Note, error)
+	CreateNote(ctx context.Context, noteTypeId int, deckId int, fields map[string]string) ([]*models.Card, error)
+	CreateClozeNote(ctx context.Context, text string, extra string, deckId int) ([]*models.Card, error)
+	EditNote(ctx context.Context, id int, fields map[string]string) ([]*models.Card, error)
+	DeleteNote(ctx context.Context, id int) error
+}
+
+type noteService struct {
+	db    *db.Database
+	clock *clock.Calendar
+}
+
+func NewNoteService(db *db.Database, clock *clock.Calendar) NoteService {
+	return &noteService{db: db, clock: clock}
+}
+
+func (ns *noteService) GetNoteTypes(ctx context.Context) ([]*models.NoteType, error) {
+	return ns.db.GetNoteTypes(ctx)
+}
+
+func (ns *noteService) GetNote(ctx context.Context, id int) (*models.Note, error) {
+	return getNote(ctx, ns.db, id)
+}
+
+// CreateNote stores a note and generates its cards. It returns the new cards.
+func (ns *noteService) CreateNote(ctx context.Context, noteTypeId int, deckId int, fields map[string]string) ([]*models.Card, error) {
+	noteType, err := ns.db.GetNoteType(ctx, noteTypeId)
+	if err != nil {
+		return nil, err
+	}
+	return createNote(ctx, ns.db, noteType, deckId, fields, ns.clock.Now())
+}
+
+// CreateClozeNote creates a note of the cloze note type, producing one card
+// per cloze number in text.
+func (ns *noteService) CreateClozeNote(ctx context.Context, text string, extra string, deckId int) ([]*models.Card, error) {
+	noteType, err := findNoteType(ctx, ns.db, func(nt *models.NoteType) bool {
+		return nt.Kind == models.ClozeNote
+	})
+	if err != nil {
+		return nil, err
+	}
+	return createNote(ctx, ns.db, noteType, deckId, map[string]string{"Text": text, "Extra": extra}, ns.clock.Now())
+}
+
+// EditNote updates the note's fields and regenerates its cards. Cards that
+// still exist keep their scheduling state. It returns the note's cards.
+func (ns *noteService) EditNote(ctx context.Context, id int, fields map[string]string) ([]*models.Card, error) {
+	note, err := getNote(ctx, ns.db, id)
+	if err != nil {
+		return nil, err
+	}
+	noteType, err := ns.db.GetNoteType(ctx, note.NoteTypeID)
+	if err != nil {
+		return nil, err
+	}
+	note.Fields = fields
+	var cards []*models.Card
+	err = ns.db.WithTx(ctx, func(tx *db.Tx) error {
+		if err := tx.EditNote(ctx, id, fields); err != nil {
+			return err
+		}
+		cards, err = syncNoteCards(ctx, tx.Database, noteType, note)
+		return err
+	})
+	return cards, err
+}
+
+// DeleteNote removes the note together with every card generated from it.
+func (ns *noteService) DeleteNote(ctx context.Context, id int) error {
+	return ns.db.DeleteNote(ctx, id)
+}
+
+// createNote stores a note created at createdAt and its cards in one
+// transaction.
+func createNote(ctx context.Context, database *db.Database, noteType *models.NoteType, deckId int, fields map[string]string, createdAt time.Time) ([]*models.Card, error) {
+	var cards []*models.Card
+	err := database.WithTx(ctx, func(tx *db.Tx) error {
+		noteId, err := tx.CreateNote(ctx, noteType.ID, deckId, fields, createdAt)
+		if err != nil {
+			return err
+		}
+		note := &models.Note{ID: noteId, NoteTypeID: noteType.ID, DeckID: deckId, Fields: fields}
+		cards, err = syncNoteCards(ctx, tx.Database, noteType, note)
+		return err
+	})
+	return cards, err
+}
+
+func findNoteType(ctx context.Context, database *db.Database, match func(*models.NoteType) bool) (*models.NoteType, error) {
+	noteTypes, err := database.GetNoteTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, noteType := range noteTypes {
+		if match(noteType) {
+			return noteType, nil
+		}
+	}
+	return nil, &RecordError{Kind: ErrNotFound, Entity: "note type"}
+}
+
+// sideFields returns the fields shown on the front and back of the card
+// generated by template when each side is exactly one {{Field}}.
+func sideFields(template *models.Template) (string, string, bool) {
+	single := func(side string) (string, bool) {
+		fields := cardtemplate.Fields(side)
+		if len(fields) != 1 || strings.TrimSpace(side) != "{{"+fields[0]+"}}" {
+			return "", false
+		}
+		return fields[0], true
+	}
+	front, ok := single(template.Front)
+	if !ok {
+		return "", "", false
+	}
+	back, ok := single(template.Back)
+	return front, back, ok
+}
+
+func getNote(ctx context.Context, database *db.Database, id int) (*models.Note, error) {
+	notes, err := database.GetNotes(ctx, db.NoteFilter{Where: sq.Eq{"ID": id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 {
+		return nil, &RecordError{Kind: ErrNotFound, Entity: "note", ID: id}
+	}
+	return notes[0], nil
+}
+
+// renderNote renders every template of noteType whose front is not empty,
+// keyed by template ordinal.
+func renderNote(noteType *models.NoteType, note *models.Note) map[int]*models.Card {
+	if noteType.Kind == models.ClozeNote {
+		return renderClozeNote(noteType, note)
+	}
+	rendered := make(map[int]*models.Card, len(noteType.Templates))
+	for _, template := range noteType.Templates {
+		front, back := cardtemplate.RenderCard(template.Front, template.Back, note.Fields)
+		if cardtemplate.IsEmpty(front) {
+			continue
+		}
+		rendered[template.Ord] = &models.Card{
+			Front:        front,
+			Back:         back,
+			ParentDeckId: note.DeckID,
+			NoteID:       note.ID,
+			Ord:          template.Ord,
+		}
+	}
+	return rendered
+}
+
+// renderClozeNote renders one card per cloze number found in the note's
+// fields, keyed by the cloze number minus one. Templates refer to the cloze
+// text with {{cloze:Field}}.
+func renderClozeNote(noteType *models.NoteType, note *models.Note) map[int]*models.Card {
+	rendered := make(map[int]*models.Card)
+	if len(noteType.Templates) == 0 {
+		return rendered
+	}
+	template := noteType.Templates[0]
+	var numbers []int
+	for _, field := range noteType.Fields {
+		for _, number := range cloze.Numbers(note.Fields[field]) {
+			if !slices.Contains(numbers, number) {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	for _, number := range numbers {
+		frontFields := maps.Clone(note.Fields)
+		backFields := maps.Clone(note.Fields)
+		for _, field := range noteType.Fields {
+			frontFields["cloze:"+field] = cloze.Front(note.Fields[field], number)
+			backFields["cloze:"+field] = cloze.Back(note.Fields[field], number)
+		}
+		front := cardtemplate.Render(template.Front, frontFields)
+		backFields[cardtemplate.FrontSide] = front
+		rendered[number-1] = &models.Card{
+			Front:        front,
+			Back:         cardtemplate.Render(template.Back, backFields),
+			ParentDeckId: note.DeckID,
+			NoteID:       note.ID,
+			Ord:          number - 1,
+		}
+	}
+	return rendered
+}
+
+// syncNoteCards brings the cards of note in line with its rendered templates:
+// existing cards are rewritten in place, missing ones are created and cards
+// whose template no longer renders are removed. Cards deleted on their own
+// are not created again. A note that renders no card at all is rejected,
+// since it would never be studied.
+func syncNoteCards(ctx context.Context, database *db.Database, noteType *models.NoteType, note *models.Note) ([]*models.Card, error) {
+	rendered := renderNote(noteType, note)
+	if len(rendered) == 0 {
+		if noteType.Kind == models.ClozeNote {
+			return nil, invalidInput("note", note.ID, "the text has no cloze deletions")
+		}
+		return nil, invalidInput("note", note.ID, "no template renders a card")
+	}
+	for _, ord := range note.DeletedOrds {
+		delete(rendered, ord)
+	}
+	existing, err := database.GetCards(ctx, db.CardFilter{Where: sq.Eq{"NoteId": note.ID}, Order: "Ord ASC"})
+	if err != nil {
+		return nil, err
+	}
+	var cards []*models.Card
+	for _, card := range existing {
+		target, found := rendered[card.Ord]
+		if !found {
+			if err := database.DeleteCard(ctx, db.CardFilter{Where: sq.Eq{"ID": card.ID}}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := database.EditCard(ctx, target.Front, target.Back, card.ID); err != nil {
+			return nil, err
+		}
+		card.Front = target.Front
+		card.Back = target.Back
+		cards = append(cards, card)
+		delete(rendered, card.Ord)
+	}
+	for _, ord := range slices.Sorted(maps.Keys(rendered)) {
+		card := rendered[ord]
+		id, err := database.AddCard(ctx, card)
+		if err != nil {
+			return nil, err
+		}
+		card.ID = id
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
@@ -0,0 +1,96 @@
+package services_test
+
+import (
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/models"
+	"memoflash/internal/services"
+	"memoflash/internal/values"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// noteType returns the id of the built-in note type called name.
+func noteType(t *testing.T, f *fixture, name string) int {
+	t.Helper()
+	noteTypes, err := f.notes.GetNoteTypes(ctx)
+	checkError(t, err, nil)
+	for _, noteType := range noteTypes {
+		if noteType.Name == name {
+			return noteType.ID
+		}
+	}
+	t.Fatalf("note type %q not found", name)
+	return 0
+}
+
+func TestEditNoteKeepsScheduling(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Japanese").Add(t, f.db)
+	fields := map[string]string{"Word": "猫", "Reading": "ねこ", "Meaning": "cat", "Example": ""}
+	cards, err := f.notes.CreateNote(ctx, noteType(t, f, "Vocabulary"), deck.ID, fields)
+	checkError(t, err, nil)
+	if len(cards) != 2 {
+		t.Fatalf("CreateNote() = %d cards, want 2", len(cards))
+	}
+	if got := dbtest.Count(t, f.db, "notes", sq.Eq{"CreatedAt": now.Unix()}); got != 1 {
+		t.Errorf("notes created at the clock's time = %d, want 1", got)
+	}
+	_, err = f.cards.ReviewCard(ctx, cards[0], values.Good, 5*time.Second)
+	checkError(t, err, nil)
+	f.clock.Advance(time.Hour)
+	_, err = f.cards.ReviewCard(ctx, cards[1], values.Hard, 5*time.Second)
+	checkError(t, err, nil)
+	before := []*models.Card{dbtest.GetCard(t, f.db, cards[0].ID), dbtest.GetCard(t, f.db, cards[1].ID)}
+
+	fields["Meaning"] = "cat, kitty"
+	edited, err := f.notes.EditNote(ctx, cards[0].NoteID, fields)
+	checkError(t, err, nil)
+	if len(edited) != 2 {
+		t.Fatalf("EditNote() = %d cards, want 2", len(edited))
+	}
+	wantBack := []string{"ねこ<br>cat, kitty<br>", "猫<br>ねこ"}
+	wantFront := []string{"猫", "cat, kitty"}
+	for i, card := range edited {
+		old := before[i]
+		stored := dbtest.GetCard(t, f.db, card.ID)
+		if card.ID != old.ID {
+			t.Errorf("card %d: ID = %d, want %d", i, card.ID, old.ID)
+		}
+		if stored.Stability != old.Stability || stored.Difficulty != old.Difficulty {
+			t.Errorf("card %d: stability, difficulty = %v, %v, want %v, %v", i, stored.Stability, stored.Difficulty, old.Stability, old.Difficulty)
+		}
+		if !stored.Interval.Equal(old.Interval) || !stored.LastStudied.Equal(old.LastStudied) {
+			t.Errorf("card %d: interval, last studied = %v, %v, want %v, %v", i, stored.Interval, stored.LastStudied, old.Interval, old.LastStudied)
+		}
+		if stored.Front != wantFront[i] || stored.Back != wantBack[i] {
+			t.Errorf("card %d: sides = %q, %q, want %q, %q", i, stored.Front, stored.Back, wantFront[i], wantBack[i])
+		}
+	}
+}
+
+func TestClozeNoteWithoutDeletions(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Geography").Add(t, f.db)
+
+	_, err := f.notes.CreateClozeNote(ctx, "Madrid is the capital", "", deck.ID)
+	checkError(t, err, services.ErrInvalidInput)
+	if got := dbtest.Count(t, f.db, "notes", nil); got != 0 {
+		t.Errorf("notes = %d, want the note rolled back", got)
+	}
+
+	cards, err := f.notes.CreateClozeNote(ctx, "{{c1::Madrid}} is the capital", "", deck.ID)
+	checkError(t, err, nil)
+	_, err = f.notes.EditNote(ctx, cards[0].NoteID, map[string]string{"Text": "Madrid is the capital"})
+	checkError(t, err, services.ErrInvalidInput)
+	stored := dbtest.GetCard(t, f.db, cards[0].ID)
+	if stored.Front != cards[0].Front {
+		t.Errorf("front = %q, want the edit rolled back to %q", stored.Front, cards[0].Front)
+	}
+	note, err := f.notes.GetNote(ctx, cards[0].NoteID)
+	checkError(t, err, nil)
+	if note.Fields["Text"] != "{{c1::Madrid}} is the capital" {
+		t.Errorf("text = %q, want the edit rolled back", note.Fields["Text"])
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/pkg/fsrs"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type PresetService interface {
+	GetPresets(ctx context.Context) ([]*models.Preset, error)
+	GetPreset(ctx context.Context, id int) (*models.Preset, error)
+	CreatePreset(ctx context.Context, preset *models.Preset) (int, error)
+	EditPreset(ctx context.Context, preset *models.Preset) error
+	DeletePreset(ctx context.Context, id int) error
+	SetDeckPreset(ctx context.Context, deckId int, presetId int) error
+}
+
+type presetService struct {
+	db *db.Database
+}
+
+func NewPresetService(db *db.Database) PresetService {
+	return &presetService{db: db}
+}
+
+func (ps *presetService) GetPresets(ctx context.Context) ([]*models.Preset, error) {
+	return ps.db.GetPresets(ctx)
+}
+
+// GetPreset returns the preset with id, or the default preset when id is
+// zero.
+func (ps *presetService) GetPreset(ctx context.Context, id int) (*models.Preset, error) {
+	return ps.db.GetPreset(ctx, id)
+}
+
+func (ps *presetService) CreatePreset(ctx context.Context, preset *models.Preset) (int, error) {
+	if strings.TrimSpace(preset.Name) == "" {
+		return 0, invalidInput("preset", 0, "the name is empty")
+	}
+	return ps.db.CreatePreset(ctx, preset)
+}
+
+func (ps *presetService) EditPreset(ctx context.Context, preset *models.Preset) error {
+	if strings.TrimSpace(preset.Name) == "" {
+		return invalidInput("preset", preset.ID, "the name is empty")
+	}
+	return ps.db.EditPreset(ctx, preset)
+}
+
+func (ps *presetService) DeletePreset(ctx context.Context, id int) error {
+	return ps.db.DeletePreset(ctx, id)
+}
+
+func (ps *presetService) SetDeckPreset(ctx context.Context, deckId int, presetId int) error {
+	return ps.db.SetDeckPreset(ctx, deckId, presetId)
+}
+
+// deckPreset returns the preset the deck is scheduled with.
+func deckPreset(ctx context.Context, database *db.Database, deckId int) (*models.Preset, error) {
+	decks, err := database.GetDecks(ctx, db.DeckFilter{Where: sq.Eq{"decks.ID": deckId}})
+	if err != nil {
+		return nil, err
+	}
+	var presetId int
+	if len(decks) > 0 {
+		presetId = decks[0].PresetID
+	}
+	return database.GetPreset(ctx, presetId)
+}
+
+// deckPresets returns the preset of every deck, keyed by deck ID, and the
+// default preset, which schedules decks missing from the map.
+func deckPresets(ctx context.Context, database *db.Database) (map[int]*models.Preset, *models.Preset, error) {
+	decks, err := database.GetDecks(ctx, db.DeckFilter{})
+	if err != nil {
+		return nil, nil, err
+	}
+	presets, err := database.GetPresets(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[int]*models.Preset, len(presets))
+	for _, preset := range presets {
+		byID[preset.ID] = preset
+	}
+	fallback := db.DefaultPreset()
+	if len(presets) > 0 {
+		fallback = presets[0]
+	}
+	deckMap := make(map[int]*models.Preset, len(decks))
+	for _, deck := range decks {
+		preset, found := byID[deck.PresetID]
+		if !found {
+			preset = fallback
+		}
+		deckMap[deck.ID] = preset
+	}
+	return deckMap, fallback, nil
+}
+
+// presetParameters returns the scheduler parameters set by preset.
+func presetParameters(preset *models.Preset) fsrs.Parameters {
+	params := fsrs.DefaultParameters()
+	if len(preset.Weights) == len(fsrs.DefaultWeights) {
+		params.Weights = preset.Weights
+	}
+	if preset.DesiredRetention > 0 && preset.DesiredRetention < 1 {
+		params.DesiredRetention = preset.DesiredRetention
+	}
+	if preset.MaximumInterval > 0 {
+		params.MaximumInterval = preset.MaximumInterval
+	}
+	params.LearningSteps = preset.LearningSteps
+	return params
+}
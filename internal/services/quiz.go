@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"math/rand/v2"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/pkg/typeanswer"
+	"slices"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// QuizOptions is the number of choices offered for each quiz question.
+const QuizOptions = 4
+
+// QuizQuestion asks for the back of Card among Options, one of which,
+// Options[Answer], is the card's own back.
+type QuizQuestion struct {
+	Card    *models.Card
+	Options []string
+	Answer  int
+}
+
+// GetQuiz builds multiple choice questions for the due cards of a deck.
+// Distractors are the backs of other cards in the deck, preferring those of
+// similar length or sharing tags. Cloze cards and cards without enough
+// distinct distractors are left out.
+func (cs *cardService) GetQuiz(ctx context.Context, deckId int) ([]*QuizQuestion, error) {
+	notCloze := sq.Expr(`(NoteId IS NULL OR NoteId NOT IN (
+		SELECT notes.ID FROM notes JOIN note_types ON note_types.ID = notes.NoteTypeId
+		WHERE note_types.Kind = ?))`, models.ClozeNote)
+	pool, err := cs.db.GetCards(ctx, db.CardFilter{
+		Where: sq.And{sq.Eq{"ParentDeckId": deckId}, notCloze},
+	})
+	if err != nil {
+		return nil, err
+	}
+	due, err := dueQueue(ctx, cs.db, cs.clock, sq.And{sq.Eq{"ParentDeckId": deckId}, notCloze})
+	if err != nil {
+		return nil, err
+	}
+	return buildQuiz(due, pool), nil
+}
+
+func buildQuiz(cards []*models.Card, pool []*models.Card) []*QuizQuestion {
+	questions := make([]*QuizQuestion, 0, len(cards))
+	for _, card := range cards {
+		distractors := pickDistractors(card, pool)
+		if len(distractors) < QuizOptions-1 {
+			continue
+		}
+		options := append(distractors, QuizOption(card.Back))
+		rand.Shuffle(len(options), func(i, j int) {
+			options[i], options[j] = options[j], options[i]
+		})
+		questions = append(questions, &QuizQuestion{
+			Card:    card,
+			Options: options,
+			Answer:  slices.Index(options, QuizOption(card.Back)),
+		})
+	}
+	return questions
+}
+
+// pickDistractors returns up to three wrong answers for card. The best
+// scoring candidates are kept and sampled so a card does not always get the
+// same distractors.
+func pickDistractors(card *models.Card, pool []*models.Card) []string {
+	seen := map[string]bool{typeanswer.Normalize(QuizOption(card.Back)): true}
+	var candidates []*models.Card
+	for _, other := range pool {
+		key := typeanswer.Normalize(QuizOption(other.Back))
+		if other.ID == card.ID || (card.NoteID != 0 && other.NoteID == card.NoteID) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, other)
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	slices.SortStableFunc(candidates, func(a, b *models.Card) int {
+		scoreA, scoreB := distractorScore(card, a), distractorScore(card, b)
+		switch {
+		case scoreA > scoreB:
+			return -1
+		case scoreA < scoreB:
+			return 1
+		}
+		return 0
+	})
+	candidates = candidates[:min(len(candidates), 2*(QuizOptions-1))]
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	distractors := make([]string, 0, QuizOptions-1)
+	for _, candidate := range candidates[:min(len(candidates), QuizOptions-1)] {
+		distractors = append(distractors, QuizOption(candidate.Back))
+	}
+	return distractors
+}
+
+// distractorScore rates how plausible other's back is as a wrong answer for
+// card: one point per shared tag plus up to one for a similar length.
+func distractorScore(card, other *models.Card) float64 {
+	a := len([]rune(QuizOption(card.Back)))
+	b := len([]rune(QuizOption(other.Back)))
+	score := 1.0
+	if max(a, b) > 0 {
+		score = float64(min(a, b)) / float64(max(a, b))
+	}
+	for _, tag := range card.Tags {
+		if tag != models.LeechTag && other.HasTag(tag) {
+			score++
+		}
+	}
+	return score
+}
+
+// QuizOption returns a card back as the plain single line text shown on a
+// quiz option.
+func QuizOption(back string) string {
+	return strings.Join(strings.Fields(typeanswer.PlainText(back)), " ")
+}
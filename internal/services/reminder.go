@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"memoflash/pkg/clock"
+	"memoflash/pkg/notify"
+	"sync"
+	"time"
+)
+
+// ReminderSettings configures the daily reminder. Times are local.
+type ReminderSettings struct {
+	Enabled bool
+	// Hour and Minute are the time of day the due cards are checked at.
+	Hour, Minute int
+	// QuietStart and QuietEnd are the hours between which no reminder is
+	// shown; a reminder falling in them waits for them to end that day.
+	// Quiet hours may span midnight, and are off when both are equal.
+	QuietStart, QuietEnd int
+	// MinDue is the number of due cards below which no reminder is shown.
+	MinDue int
+}
+
+// quiet reports whether t falls in the quiet hours.
+func (s ReminderSettings) quiet(t time.Time) bool {
+	hour := t.Hour()
+	if s.QuietStart <= s.QuietEnd {
+		return hour >= s.QuietStart && hour < s.QuietEnd
+	}
+	return hour >= s.QuietStart || hour < s.QuietEnd
+}
+
+// Reminder raises a notification once a day when cards are due. Check does
+// one round of it; Run calls Check on a timer. Reminders only come while Run
+// is running, that is while the app is open; a reminder missed while it was
+// closed is shown once it starts again the same day.
+type Reminder struct {
+	cards    CardService
+	notifier notify.Notifier
+	clock    clock.Clock
+
+	// mu guards settings only, so that SetSettings never waits on a check.
+	mu       sync.Mutex
+	settings ReminderSettings
+	// checking serialises Check, and guards checked: the time of the last
+	// reminder checked for.
+	checking sync.Mutex
+	checked  time.Time
+}
+
+// NewReminder returns a Reminder counting the due cards with cards and
+// showing reminders with notifier.
+func NewReminder(cards CardService, notifier notify.Notifier, clock clock.Clock, settings ReminderSettings) *Reminder {
+	return &Reminder{cards: cards, notifier: notifier, clock: clock, settings: settings}
+}
+
+// SetSettings changes the settings from the next check on. It is safe to
+// call while Run is running.
+func (r *Reminder) SetSettings(settings ReminderSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings = settings
+}
+
+// Check shows today's reminder if its time has come and it was not checked
+// for yet. The due cards are counted once per reminder, whether or not there
+// are enough of them to show it. It reports whether a reminder was shown.
+func (r *Reminder) Check(ctx context.Context) (bool, error) {
+	r.checking.Lock()
+	defer r.checking.Unlock()
+	r.mu.Lock()
+	settings := r.settings
+	r.mu.Unlock()
+	if !settings.Enabled {
+		return false, nil
+	}
+	now := r.clock.Now().In(time.Local)
+	year, month, day := now.Date()
+	at := time.Date(year, month, day, settings.Hour, settings.Minute, 0, 0, time.Local)
+	if now.Before(at) || !r.checked.Before(at) || settings.quiet(now) {
+		return false, nil
+	}
+	due, err := r.cards.CountDueCards(ctx)
+	if err != nil {
+		return false, err
+	}
+	r.checked = at
+	if due == 0 || due < settings.MinDue {
+		return false, nil
+	}
+	body := fmt.Sprintf("%d cards are due for review", due)
+	if due == 1 {
+		body = "1 card is due for review"
+	}
+	return true, r.notifier.Notify(ctx, notify.Notification{Title: "Time to study", Body: body})
+}
+
+// Run calls Check every interval until ctx is done. Errors are passed to
+// onError, which may be nil.
+func (r *Reminder) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Check(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
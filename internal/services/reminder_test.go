@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/services"
+	"memoflash/pkg/notify"
+	"testing"
+	"time"
+)
+
+// fakeNotifier records the notifications instead of showing them.
+type fakeNotifier struct {
+	shown []notify.Notification
+	err   error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	n.shown = append(n.shown, notification)
+	return n.err
+}
+
+func TestReminder(t *testing.T) {
+	evening := services.ReminderSettings{Enabled: true, Hour: 19, Minute: 30, QuietStart: 22, QuietEnd: 8, MinDue: 1}
+	with := func(change func(s *services.ReminderSettings)) services.ReminderSettings {
+		s := evening
+		change(&s)
+		return s
+	}
+	type check struct {
+		at   time.Time
+		want bool
+	}
+	tests := []struct {
+		name     string
+		settings services.ReminderSettings
+		due      int
+		checks   []check
+	}{
+		{
+			name:     "disabled",
+			settings: with(func(s *services.ReminderSettings) { s.Enabled = false }),
+			due:      3,
+			checks:   []check{{day(0, 19, 30), false}, {day(0, 21, 0), false}},
+		},
+		{
+			name:     "once a day",
+			settings: evening,
+			due:      3,
+			checks: []check{
+				{day(0, 19, 29), false},
+				{day(0, 19, 30), true},
+				{day(0, 19, 31), false},
+				{day(1, 9, 0), false},
+				{day(1, 20, 0), true},
+			},
+		},
+		{
+			name:     "too few due",
+			settings: with(func(s *services.ReminderSettings) { s.MinDue = 4 }),
+			due:      3,
+			checks:   []check{{day(0, 19, 30), false}, {day(0, 20, 0), false}},
+		},
+		{
+			name:     "nothing due",
+			settings: with(func(s *services.ReminderSettings) { s.MinDue = 0 }),
+			checks:   []check{{day(0, 19, 30), false}},
+		},
+		{
+			name:     "quiet hours",
+			settings: with(func(s *services.ReminderSettings) { s.Hour = 23 }),
+			due:      3,
+			checks:   []check{{day(0, 23, 30), false}, {day(1, 7, 0), false}, {day(1, 23, 45), false}},
+		},
+		{
+			name:     "waits for quiet hours to end",
+			settings: with(func(s *services.ReminderSettings) { s.Hour, s.Minute = 7, 0 }),
+			due:      3,
+			checks:   []check{{day(1, 7, 0), false}, {day(1, 7, 59), false}, {day(1, 8, 0), true}},
+		},
+		{
+			name:     "quiet hours off",
+			settings: with(func(s *services.ReminderSettings) { s.Hour, s.QuietStart, s.QuietEnd = 23, 0, 0 }),
+			due:      3,
+			checks:   []check{{day(0, 23, 30), true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			deck := dbtest.Deck("Spanish").Add(t, f.db)
+			for range tt.due {
+				dbtest.Card(deck.ID).Add(t, f.db)
+			}
+			notifier := &fakeNotifier{}
+			reminder := services.NewReminder(f.cards, notifier, f.clock, tt.settings)
+			for _, c := range tt.checks {
+				f.clock.Set(c.at)
+				shown, err := reminder.Check(ctx)
+				checkError(t, err, nil)
+				if shown != c.want {
+					t.Errorf("Check() at %v = %v, want %v", c.at, shown, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReminderNotification(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	dbtest.Card(deck.ID).Add(t, f.db)
+	dbtest.Card(deck.ID).Add(t, f.db)
+	dbtest.Card(deck.ID).Due(tomorrow).Add(t, f.db)
+	failed := errors.New("no notification service")
+	notifier := &fakeNotifier{err: failed}
+	settings := services.ReminderSettings{Enabled: true, Hour: now.Hour()}
+	reminder := services.NewReminder(f.cards, notifier, f.clock, settings)
+
+	if _, err := reminder.Check(ctx); !errors.Is(err, failed) {
+		t.Fatalf("Check() error = %v, want %v", err, failed)
+	}
+	want := notify.Notification{Title: "Time to study", Body: "2 cards are due for review"}
+	if len(notifier.shown) != 1 || notifier.shown[0] != want {
+		t.Errorf("shown = %+v, want %+v", notifier.shown, want)
+	}
+
+	// Settings changed later apply from the next check on.
+	settings.Hour++
+	reminder.SetSettings(settings)
+	f.clock.Set(day(0, settings.Hour, 0))
+	checkError(t, f.cards.DeleteCard(ctx, 1), nil)
+	notifier.err = nil
+	shown, err := reminder.Check(ctx)
+	checkError(t, err, nil)
+	want.Body = "1 card is due for review"
+	if !shown || len(notifier.shown) != 2 || notifier.shown[1] != want {
+		t.Errorf("shown = %+v, want %+v last", notifier.shown, want)
+	}
+}
+
+// blockingNotifier holds Notify until release is closed.
+type blockingNotifier struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	close(n.entered)
+	<-n.release
+	return nil
+}
+
+func TestReminderSetSettingsDuringCheck(t *testing.T) {
+	f := newFixture(t)
+	deck := dbtest.Deck("Spanish").Add(t, f.db)
+	dbtest.Card(deck.ID).Add(t, f.db)
+	notifier := &blockingNotifier{entered: make(chan struct{}), release: make(chan struct{})}
+	settings := services.ReminderSettings{Enabled: true, Hour: now.Hour()}
+	reminder := services.NewReminder(f.cards, notifier, f.clock, settings)
+
+	checked := make(chan error)
+	go func() {
+		_, err := reminder.Check(ctx)
+		checked <- err
+	}()
+	<-notifier.entered
+	set := make(chan struct{})
+	go func() {
+		settings.MinDue = 5
+		reminder.SetSettings(settings)
+		close(set)
+	}()
+	select {
+	case <-set:
+	case <-time.After(time.Second):
+		t.Error("SetSettings() waited for the notification")
+	}
+	close(notifier.release)
+	checkError(t, <-checked, nil)
+}
@@ -1,36 +1,37 @@
 package services
 
-type Service struct {
-	DeckService
-	CardService
-}
+import (
+	"errors"
+	"memoflash/internal/db"
+	"memoflash/pkg/clock"
+)
 
-// type states struct {
-// 	DeckService
-// 	CardService
-// }
+// Errors returned by the services, to be matched with errors.Is. They come
+// from the database layer, or from the services rejecting input before it
+// reaches it.
+var (
+	ErrNotFound     = db.ErrNotFound
+	ErrConflict     = db.ErrConflict
+	ErrInvalidInput = db.ErrInvalidInput
+)
 
-// func (states *states) GetStreak() {
-// 	stats, err := states.GetAllDueCards()
-// 	if err != nil {
-// 		return 0, err
-// 	}
-// 	var lastTimeUpdated = stats.LastTimeUpdated
-// 	var streak = stats.DayStreak
-// 	if lastTimeUpdated.Format("2006-01-02") == time.Now().Format("2006-01-02") {
-// 		return stats.DayStreak, nil
-// 	}
-// 	isStudied, err := cs.isYesterdayStudied()
-// 	if err != nil {
-// 		return 0, err
-// 	}
+// RecordError describes a failed operation on a record, such as a card or a
+// deck. See db.RecordError.
+type RecordError = db.RecordError
 
-// 	if isStudied {
-// 		streak = streak + 1
-// 	} else {
-// 		streak = 0
-// 	}
-// 	err = cs.db.UpdateStats(map[string]any{"dayStreak": streak, "lastTimeUpdated": time.Now().Unix()})
-// 	return streak, err
+// invalidInput reports a value of entity rejected for reason.
+func invalidInput(entity string, id int, reason string) error {
+	return &RecordError{Kind: ErrInvalidInput, Entity: entity, ID: id, Err: errors.New(reason)}
+}
 
-// }
+type Service struct {
+	// Calendar defines the learning day every service counts with.
+	Calendar *clock.Calendar
+	DeckService
+	CardService
+	NoteService
+	PresetService
+	SessionService
+	ActivityService
+	GoalService
+}
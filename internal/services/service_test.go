@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"memoflash/internal/db"
+	"memoflash/internal/db/dbtest"
+	"memoflash/internal/services"
+	"memoflash/pkg/clock"
+	"os"
+	"testing"
+	"time"
+)
+
+var ctx = context.Background()
+
+// zone is ahead of UTC so that the learning day and the UTC date disagree
+// for part of the day.
+var zone = time.FixedZone("UTC+10", 10*60*60)
+
+// rolloverHour is the hour the tests' learning days start at.
+const rolloverHour = 4
+
+// now is the time the fake clock starts at, mid-afternoon so that the day's
+// boundaries are a few hours away.
+var now = time.Date(2025, time.March, 14, 15, 0, 0, 0, zone)
+
+// tomorrow is the start of the learning day after the one of now.
+var tomorrow = day(1, rolloverHour, 0)
+
+// day returns the time n days after now at hour:min.
+func day(n int, hour, min int) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()+n, hour, min, 0, 0, zone)
+}
+
+func TestMain(m *testing.M) {
+	time.Local = zone
+	os.Exit(m.Run())
+}
+
+type fixture struct {
+	db       *db.Database
+	clock    *clock.Fake
+	day      *clock.Calendar
+	cards    services.CardService
+	decks    services.DeckService
+	notes    services.NoteService
+	presets  services.PresetService
+	activity services.ActivityService
+	goals    services.GoalService
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	database := dbtest.New(t)
+	fake := clock.NewFake(now)
+	calendar := clock.NewCalendar(fake, rolloverHour)
+	return &fixture{
+		db:       database,
+		clock:    fake,
+		day:      calendar,
+		cards:    services.NewCardService(database, calendar),
+		decks:    services.NewDeckService(database, calendar),
+		notes:    services.NewNoteService(database, calendar),
+		presets:  services.NewPresetService(database),
+		activity: services.NewActivityService(database, calendar),
+		goals:    services.NewGoalService(database, calendar),
+	}
+}
+
+func checkError(t *testing.T, err, want error) {
+	t.Helper()
+	if want == nil {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+	if !errors.Is(err, want) {
+		t.Fatalf("error = %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"memoflash/internal/db"
+	"memoflash/internal/models"
+	"memoflash/pkg/clock"
+	"time"
+)
+
+type SessionService interface {
+	SaveSession(ctx context.Context, session *models.StudySession) error
+	GetSessions(ctx context.Context, limit int) ([]*models.StudySession, error)
+	GetNextDue(ctx context.Context, deckId int) (time.Time, error)
+}
+
+type sessionService struct {
+	db    *db.Database
+	clock *clock.Calendar
+}
+
+func NewSessionService(db *db.Database, clock *clock.Calendar) *sessionService {
+	return &sessionService{db: db, clock: clock}
+}
+
+func (ss *sessionService) SaveSession(ctx context.Context, session *models.StudySession) error {
+	id, err := ss.db.AddStudySession(ctx, session)
+	if err != nil {
+		return err
+	}
+	session.ID = id
+	return nil
+}
+
+// GetSessions returns the latest limit sessions, newest first.
+func (ss *sessionService) GetSessions(ctx context.Context, limit int) ([]*models.StudySession, error) {
+	return ss.db.GetStudySessions(ctx, uint64(limit))
+}
+
+// GetNextDue returns when the next card of deckId, or of any deck when
+// deckId is zero, becomes due: the start of the first learning day after
+// today that has cards scheduled. The zero time means nothing is scheduled.
+func (ss *sessionService) GetNextDue(ctx context.Context, deckId int) (time.Time, error) {
+	next, err := ss.db.NextDue(ctx, deckId, ss.clock.Tomorrow())
+	if err != nil || next.IsZero() {
+		return next, err
+	}
+	return ss.clock.DayStart(next), nil
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"memoflash/internal/models"
 	"memoflash/internal/services"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 
 	"cogentcore.org/core/colors"
 	"cogentcore.org/core/core"
+	"cogentcore.org/core/events"
 	"cogentcore.org/core/icons"
 	"cogentcore.org/core/styles"
 	"cogentcore.org/core/styles/states"
@@ -61,16 +63,28 @@ type App struct {
 	Services *services.Service
 	Settings *AppSettings
 	Tabs     *core.Tabs
+	studyTab *core.Tab
 	Decks    []*models.Deck
 	DeckMap  map[int]*models.Deck
 }
 
 func NewMemoFlashWindow(service *services.Service) {
 	appName := "MemoFlash"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	appContext = ctx
 	b := core.NewBody(appName).SetTitle(appName)
 	app := tree.New[App](b)
 	app.Services = service
+	Settings.calendar = service.Calendar
 	app.CreateApp()
+	app.startReminders(ctx)
+	app.startDueIndicator(ctx)
+	if Settings.StartMinimized {
+		app.OnShow(func(e events.Event) {
+			app.minimize()
+		})
+	}
 	b.RunMainWindow()
 }
 
@@ -110,6 +124,7 @@ func (app *App) createTabPanels(tabs *core.Tabs) {
 
 	frameStudy, tabStudy := tabs.NewTab("Study")
 	tabStudy.SetIcon(icons.School)
+	app.studyTab = tabStudy
 	tree.AddChildAt(frameStudy, "decks-section", func(studyTab *StudyTab) {
 		studyTab.deckrepo = app
 		studyTab.services = app.Services
@@ -122,4 +137,15 @@ func (app *App) createTabPanels(tabs *core.Tabs) {
 		deckTab.deckrepo = app
 		deckTab.service = app.Services
 	})
+
+	frameStats, statsTab := tabs.NewTab("Statistics")
+	statsTab.SetIcon(icons.BarChart)
+	tree.AddChildAt(frameStats, "stats-section", func(statsTab *StatsTab) {
+		statsTab.deckrepo = app
+		statsTab.services = app.Services
+	})
+
+	frameSettings, settingsTab := tabs.NewTab("Settings")
+	settingsTab.SetIcon(icons.Settings)
+	tree.AddChildAt(frameSettings, "settings-section", func(settingsTab *SettingsTab) {})
 }
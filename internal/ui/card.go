@@ -1,7 +1,12 @@
 package ui
 
 import (
+	"fmt"
+	"image/color"
 	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"strings"
+	"time"
 
 	"cogentcore.org/core/colors"
 	"cogentcore.org/core/core"
@@ -13,15 +18,40 @@ import (
 	"cogentcore.org/core/tree"
 )
 
+// FlagColors holds the color of each values.Flag; NoFlag has none.
+var FlagColors = []color.Color{
+	nil,
+	color.RGBA{239, 83, 80, 255},
+	color.RGBA{255, 167, 38, 255},
+	color.RGBA{102, 187, 106, 255},
+	color.RGBA{66, 165, 245, 255},
+}
+
+// flagColor returns the color of flag, or nil when it has none or is not a
+// known flag.
+func flagColor(flag values.Flag) color.Color {
+	if flag < 0 || int(flag) >= len(FlagColors) {
+		return nil
+	}
+	return FlagColors[flag]
+}
+
 type Card struct {
 	core.Frame
-	Data     *models.Card
-	onDelete func()
-	onEdit   func()
+	Data *models.Card
+	// now is the time the card's buried status is shown for.
+	now       time.Time
+	onDelete  func()
+	onEdit    func()
+	onSuspend func()
+	onBury    func()
+	onFlag    func(values.Flag)
+	onType    func()
 }
 
-func (card *Card) SetData(data *models.Card) {
+func (card *Card) SetData(data *models.Card, now time.Time) {
 	card.Data = data
+	card.now = now
 }
 
 func (card *Card) SetEdit(f func()) {
@@ -30,6 +60,18 @@ func (card *Card) SetEdit(f func()) {
 func (card *Card) SetDelete(f func()) {
 	card.onDelete = f
 }
+func (card *Card) SetSuspend(f func()) {
+	card.onSuspend = f
+}
+func (card *Card) SetBury(f func()) {
+	card.onBury = f
+}
+func (card *Card) SetFlag(f func(values.Flag)) {
+	card.onFlag = f
+}
+func (card *Card) SetTypeAnswer(f func()) {
+	card.onType = f
+}
 func (card *Card) Init() {
 	card.Frame.Init()
 	card.Styler(func(s *styles.Style) {
@@ -38,7 +80,10 @@ func (card *Card) Init() {
 		s.Background = colors.Scheme.SurfaceContainer
 		s.Border.Radius = styles.BorderRadiusSmall
 		s.Padding.Set(units.Dp(17))
-
+		if card.Data != nil && flagColor(card.Data.Flag) != nil {
+			s.Border.Width.Left = units.Dp(4)
+			s.Border.Color.Left = colors.Uniform(flagColor(card.Data.Flag))
+		}
 	})
 
 	tree.AddChild(card, func(w *core.Text) {
@@ -71,6 +116,20 @@ func (card *Card) Init() {
 		})
 	})
 
+	tree.AddChild(card, func(w *core.Text) {
+		w.SetType(core.TextLabelMedium)
+		w.Styler(func(s *styles.Style) {
+			s.Color = colors.Scheme.OnSurfaceVariant
+			s.Margin.SetTop(units.Dp(6))
+			if card.statusText() == "" {
+				s.Display = styles.DisplayNone
+			}
+		})
+		w.Updater(func() {
+			w.SetText(card.statusText())
+		})
+	})
+
 	tree.AddChild(card, func(w *core.Frame) {
 		w.Styler(func(s *styles.Style) {
 			s.Margin.SetTop(units.Dp(10))
@@ -102,9 +161,83 @@ func (card *Card) Init() {
 			w.OnClick(func(e events.Event) {
 				if card.onDelete != nil {
 					card.onDelete()
-					card.onDelete = nil
 				}
 			})
 		})
+
+		tree.AddChild(w, func(w *core.Button) {
+			w.SetType(core.ButtonAction)
+			w.SetIcon(icons.MoreVert)
+			w.SetTooltip("More actions")
+			w.SetMenu(card.makeMoreMenu)
+		})
+	})
+}
+
+func (card *Card) makeMoreMenu(m *core.Scene) {
+	if card.Data == nil {
+		return
+	}
+	suspendText := "Suspend"
+	if card.Data.Suspended {
+		suspendText = "Unsuspend"
+	}
+	core.NewButton(m).SetText(suspendText).SetIcon(icons.Pause).OnClick(func(e events.Event) {
+		if card.onSuspend != nil {
+			card.onSuspend()
+		}
+	})
+	buryText := "Bury until tomorrow"
+	if card.Data.IsBuried(card.now) {
+		buryText = "Unbury"
+	}
+	core.NewButton(m).SetText(buryText).SetIcon(icons.VisibilityOff).OnClick(func(e events.Event) {
+		if card.onBury != nil {
+			card.onBury()
+		}
 	})
+	typeText := "Type the answer"
+	if card.Data.TypeAnswer {
+		typeText = "Don't type the answer"
+	}
+	core.NewButton(m).SetText(typeText).SetIcon(icons.Keyboard).OnClick(func(e events.Event) {
+		if card.onType != nil {
+			card.onType()
+		}
+	})
+	core.NewSeparator(m)
+	for flag, name := range values.FlagNames {
+		btn := core.NewButton(m).SetText(name + " flag").SetIcon(icons.FlagFill)
+		if flag == int(values.NoFlag) {
+			btn.SetText("Remove flag").SetIcon(icons.Flag)
+		}
+		btn.Styler(func(s *styles.Style) {
+			if FlagColors[flag] != nil {
+				s.Color = colors.Uniform(FlagColors[flag])
+			}
+		})
+		btn.OnClick(func(e events.Event) {
+			if card.onFlag != nil {
+				card.onFlag(values.Flag(flag))
+			}
+		})
+	}
+}
+
+// statusText describes why the card is kept out of the study queue and
+// whether it is a leech.
+func (card *Card) statusText() string {
+	if card.Data == nil {
+		return ""
+	}
+	var status []string
+	if card.Data.IsLeech() {
+		status = append(status, fmt.Sprintf("Leech (%d lapses)", card.Data.Lapses))
+	}
+	if card.Data.Suspended {
+		status = append(status, "Suspended")
+	} else if card.Data.IsBuried(card.now) {
+		status = append(status, "Buried until "+card.Data.BuriedUntil.Format("Jan 2 15:04"))
+	}
+	return strings.Join(status, " · ")
 }
@@ -24,7 +24,12 @@ type Deck struct {
 	index     int
 	onExplore func()
 	onAddCard func()
+	onAddNote func()
 	onEdit    func()
+	onOptions func()
+	onCustom  func()
+	onCram    func()
+	onQuiz    func()
 	onStudy   func()
 	onMore    func()
 	onDelete  func()
@@ -159,6 +164,14 @@ func (deck *Deck) Init() {
 							deck.onAddCard()
 						}
 					})
+				core.NewButton(m).
+					SetText("Add Note").
+					SetIcon(icons.NoteAdd).
+					OnClick(func(e events.Event) {
+						if deck.onAddNote != nil {
+							deck.onAddNote()
+						}
+					})
 				core.NewButton(m).
 					SetText("Edit").
 					SetIcon(icons.Edit).
@@ -167,6 +180,40 @@ func (deck *Deck) Init() {
 							deck.onEdit()
 						}
 					})
+				core.NewButton(m).
+					SetText("Cram Deck").
+					SetIcon(icons.Replay).
+					SetTooltip("Flip through every card without changing its schedule").
+					OnClick(func(e events.Event) {
+						if deck.onCram != nil {
+							deck.onCram()
+						}
+					})
+				core.NewButton(m).
+					SetText("Quiz").
+					SetIcon(icons.Quiz).
+					SetTooltip("Answer due cards as multiple choice questions").
+					OnClick(func(e events.Event) {
+						if deck.onQuiz != nil {
+							deck.onQuiz()
+						}
+					})
+				core.NewButton(m).
+					SetText("Custom Study").
+					SetIcon(icons.School).
+					OnClick(func(e events.Event) {
+						if deck.onCustom != nil {
+							deck.onCustom()
+						}
+					})
+				core.NewButton(m).
+					SetText("Options").
+					SetIcon(icons.Settings).
+					OnClick(func(e events.Event) {
+						if deck.onOptions != nil {
+							deck.onOptions()
+						}
+					})
 			})
 			i.OnClick(func(e events.Event) {
 				i.ShowContextMenu(e)
@@ -202,9 +249,25 @@ func (deck *Deck) OnAddCard(f func()) {
 	deck.onAddCard = f
 }
 
+func (deck *Deck) OnAddNote(f func()) {
+	deck.onAddNote = f
+}
+
 func (deck *Deck) OnEdit(f func()) {
 	deck.onEdit = f
 }
+func (deck *Deck) OnOptions(f func()) {
+	deck.onOptions = f
+}
+func (deck *Deck) OnCustomStudy(f func()) {
+	deck.onCustom = f
+}
+func (deck *Deck) OnCram(f func()) {
+	deck.onCram = f
+}
+func (deck *Deck) OnQuiz(f func()) {
+	deck.onQuiz = f
+}
 
 func (deck *Deck) OnStudy(f func()) {
 	deck.onStudy = f
@@ -0,0 +1,333 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"memoflash/internal/models"
+	"memoflash/internal/utils"
+	"memoflash/internal/values"
+	"memoflash/pkg/fsrs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cogentcore.org/core/colors"
+	"cogentcore.org/core/core"
+	"cogentcore.org/core/events"
+	"cogentcore.org/core/icons"
+	"cogentcore.org/core/styles"
+	"cogentcore.org/core/styles/states"
+	"cogentcore.org/core/styles/units"
+	"cogentcore.org/core/tree"
+)
+
+// ShowDeckOptions opens the option preset editor for deck. The deck can be
+// switched to another preset, and presets can be added, edited and deleted.
+// Edits to a preset apply to every deck using it.
+func (dt *DeckTab) ShowDeckOptions(deck *models.Deck) {
+	ctx, cancel := queryContext(dt)
+	defer cancel()
+	presets, err := dt.service.GetPresets(ctx)
+	if err != nil {
+		errorSnackbar(dt, err, "Error Getting Presets")
+		return
+	}
+	if len(presets) == 0 {
+		core.MessageSnackbar(dt, "No option presets found")
+		return
+	}
+	selected := slices.IndexFunc(presets, func(preset *models.Preset) bool {
+		return preset.ID == deck.PresetID
+	})
+	if selected < 0 {
+		selected = 0
+	}
+	edited := make(map[int]bool)
+	invalid := make(map[string]bool)
+
+	d := core.NewBody("Deck options")
+	core.NewText(d).SetType(core.TextBodyMedium).SetText("Options for " + deck.Title)
+
+	presetRow := core.NewFrame(d)
+	presetRow.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 0)
+		s.Align.Items = styles.Center
+	})
+	core.NewText(presetRow).SetText("Preset")
+	core.NewStretch(presetRow)
+	presetChooser := core.NewChooser(presetRow)
+	presetChooser.Updater(func() {
+		names := make([]string, len(presets))
+		for i, preset := range presets {
+			names[i] = preset.Name
+		}
+		presetChooser.SetStrings(names...)
+		presetChooser.SetCurrentIndex(selected)
+	})
+	addButton := core.NewButton(presetRow).SetType(core.ButtonAction).SetIcon(icons.Add)
+	addButton.SetTooltip("Add a preset copied from this one")
+	deleteButton := core.NewButton(presetRow).SetType(core.ButtonAction).SetIcon(icons.Delete)
+	deleteButton.SetTooltip("Delete this preset")
+	deleteButton.Updater(func() {
+		deleteButton.SetState(selected == 0, states.Disabled)
+	})
+
+	form := core.NewFrame(d)
+	form.Styler(func(s *styles.Style) {
+		s.Direction = styles.Column
+		s.Grow.Set(1, 0)
+	})
+	form.Maker(func(p *tree.Plan) {
+		makePresetForm(p, presets[selected], func() {
+			edited[presets[selected].ID] = true
+		}, func(field string, err error) {
+			invalid[field] = err != nil
+			d.Scene.Update()
+		})
+	})
+
+	refresh := func() {
+		clear(invalid)
+		presetRow.Update()
+		form.Update()
+	}
+	presetChooser.OnChange(func(e events.Event) {
+		selected = presetChooser.CurrentIndex
+		refresh()
+	})
+	addButton.OnClick(func(e events.Event) {
+		preset := *presets[selected]
+		preset.Name = fmt.Sprintf("%s copy", preset.Name)
+		for slices.ContainsFunc(presets, func(p *models.Preset) bool { return p.Name == preset.Name }) {
+			preset.Name += " copy"
+		}
+		ctx, cancel := queryContext(d)
+		defer cancel()
+		id, err := dt.service.CreatePreset(ctx, &preset)
+		if err != nil {
+			errorSnackbar(dt, err, "Error Creating Preset")
+			return
+		}
+		preset.ID = id
+		presets = append(presets, &preset)
+		selected = len(presets) - 1
+		refresh()
+	})
+	deleteButton.OnClick(func(e events.Event) {
+		preset := presets[selected]
+		message := fmt.Sprintf("Delete %q? Decks using it switch to %q.", preset.Name, presets[0].Name)
+		WarningDialog(presetRow, "Delete preset", message, "Delete", func() {
+			ctx, cancel := queryContext(d)
+			defer cancel()
+			if err := dt.service.DeletePreset(ctx, preset.ID); err != nil {
+				errorSnackbar(dt, err, "Error Deleting Preset")
+				return
+			}
+			presets = slices.Delete(presets, selected, selected+1)
+			for _, item := range dt.deckrepo.GetDecks() {
+				if item.PresetID == preset.ID {
+					item.PresetID = 0
+				}
+			}
+			selected = 0
+			refresh()
+		})
+	})
+
+	d.AddBottomBar(func(bar *core.Frame) {
+		d.AddCancel(bar)
+		save := d.AddOK(bar).SetText("Save")
+		save.Updater(func() {
+			save.SetState(slices.Contains(slices.Collect(maps.Values(invalid)), true), states.Disabled)
+		})
+		save.OnClick(func(e events.Event) {
+			ctx, cancel := queryContext(d)
+			defer cancel()
+			for _, preset := range presets {
+				if !edited[preset.ID] {
+					continue
+				}
+				if err := dt.service.EditPreset(ctx, preset); err != nil {
+					errorSnackbar(dt, err, "Error Saving Preset")
+					return
+				}
+			}
+			presetId := presets[selected].ID
+			if selected == 0 {
+				presetId = 0
+			}
+			if err := dt.service.SetDeckPreset(ctx, deck.ID, presetId); err != nil {
+				errorSnackbar(dt, err, "Error Saving Deck Options")
+				return
+			}
+			deck.PresetID = presetId
+		})
+	})
+	dialog := d.NewDialog(dt)
+	dialog.SetDisplayTitle(true)
+	dialog.Run()
+}
+
+// makePresetForm adds the fields editing preset. onEdit runs after every
+// change and onValidate reports whether a text field holds a usable value.
+func makePresetForm(p *tree.Plan, preset *models.Preset, onEdit func(), onValidate func(field string, err error)) {
+	optionRow := func(name, title string, add func(row *core.Frame)) {
+		tree.AddAt(p, fmt.Sprintf("%d-%s", preset.ID, name), func(row *core.Frame) {
+			row.Styler(func(s *styles.Style) {
+				s.Grow.Set(1, 0)
+				s.Align.Items = styles.Center
+				s.Gap.Set(units.Dp(8))
+			})
+			core.NewText(row).SetText(title).Styler(func(s *styles.Style) {
+				s.SetTextWrap(false)
+			})
+			core.NewStretch(row)
+			add(row)
+		})
+	}
+	spinner := func(row *core.Frame, value *int, min, max float32) {
+		sp := core.NewSpinner(row).SetMin(min).SetMax(max).SetStep(1)
+		sp.SetValue(float32(*value))
+		sp.OnChange(func(e events.Event) {
+			*value = int(sp.Value)
+			onEdit()
+		})
+	}
+	heading := func(name, title string) {
+		tree.AddAt(p, fmt.Sprintf("%d-%s", preset.ID, name), func(w *core.Text) {
+			w.SetText(title).SetType(core.TextTitleSmall)
+			w.Styler(func(s *styles.Style) {
+				s.Margin.SetTop(units.Dp(10))
+				s.Color = colors.Scheme.Primary.Base
+			})
+		})
+	}
+
+	optionRow("name", "Preset name", func(row *core.Frame) {
+		tf := core.NewTextField(row).SetText(preset.Name)
+		tf.SetValidator(func() error {
+			err := error(nil)
+			if strings.TrimSpace(tf.Text()) == "" {
+				err = fmt.Errorf("name is required")
+			}
+			onValidate("name", err)
+			return err
+		})
+		tf.OnChange(func(e events.Event) {
+			preset.Name = strings.TrimSpace(tf.Text())
+			onEdit()
+		})
+	})
+
+	heading("limits", "Daily limits")
+	optionRow("new-per-day", "New cards per day", func(row *core.Frame) {
+		spinner(row, &preset.NewPerDay, 0, 9999)
+	})
+	optionRow("reviews-per-day", "Reviews per day", func(row *core.Frame) {
+		spinner(row, &preset.ReviewsPerDay, 0, 9999)
+	})
+
+	heading("scheduling", "Scheduling")
+	optionRow("retention", "Desired retention", func(row *core.Frame) {
+		sp := core.NewSpinner(row).SetMin(0.7).SetMax(0.99).SetStep(0.01)
+		sp.SetFormat("%.2f")
+		sp.SetValue(float32(preset.DesiredRetention))
+		sp.OnChange(func(e events.Event) {
+			preset.DesiredRetention = float64(sp.Value)
+			onEdit()
+		})
+	})
+	optionRow("max-interval", "Maximum interval (days)", func(row *core.Frame) {
+		spinner(row, &preset.MaximumInterval, 1, 36500)
+	})
+	optionRow("steps", "Learning steps", func(row *core.Frame) {
+		tf := core.NewTextField(row).SetText(utils.FormatSteps(preset.LearningSteps))
+		tf.SetPlaceholder("1m 10m")
+		tf.SetTooltip("Space separated, e.g. 1m 10m 1h. Failed cards come back after the first step")
+		tf.SetValidator(func() error {
+			_, err := utils.ParseSteps(tf.Text())
+			onValidate("steps", err)
+			return err
+		})
+		tf.OnChange(func(e events.Event) {
+			if steps, err := utils.ParseSteps(tf.Text()); err == nil {
+				preset.LearningSteps = steps
+				onEdit()
+			}
+		})
+	})
+	optionRow("weights", "FSRS weights", func(row *core.Frame) {
+		tf := core.NewTextField(row).SetText(formatWeights(preset.Weights))
+		tf.SetPlaceholder("Default")
+		tf.SetTooltip(fmt.Sprintf("%d comma separated numbers, or empty for the defaults", len(fsrs.DefaultWeights)))
+		tf.SetValidator(func() error {
+			_, err := parseWeights(tf.Text())
+			onValidate("weights", err)
+			return err
+		})
+		tf.OnChange(func(e events.Event) {
+			if weights, err := parseWeights(tf.Text()); err == nil {
+				preset.Weights = weights
+				onEdit()
+			}
+		})
+	})
+
+	heading("leeches", "Leeches")
+	optionRow("leech-threshold", "Lapses before a card is a leech", func(row *core.Frame) {
+		spinner(row, &preset.LeechThreshold, 1, 99)
+	})
+	optionRow("leech-action", "Leech action", func(row *core.Frame) {
+		ch := core.NewChooser(row).SetStrings(values.LeechActionNames...)
+		ch.SetCurrentIndex(int(preset.LeechAction))
+		ch.OnChange(func(e events.Event) {
+			preset.LeechAction = values.LeechAction(ch.CurrentIndex)
+			onEdit()
+		})
+	})
+
+	heading("burying", "Sibling burying")
+	optionRow("bury-new", "Bury new siblings until tomorrow", func(row *core.Frame) {
+		sw := core.NewSwitch(row).SetChecked(preset.BuryNew)
+		sw.OnChange(func(e events.Event) {
+			preset.BuryNew = sw.IsChecked()
+			onEdit()
+		})
+	})
+	optionRow("bury-review", "Bury review siblings until tomorrow", func(row *core.Frame) {
+		sw := core.NewSwitch(row).SetChecked(preset.BuryReview)
+		sw.OnChange(func(e events.Event) {
+			preset.BuryReview = sw.IsChecked()
+			onEdit()
+		})
+	})
+}
+
+func formatWeights(weights []float64) string {
+	formatted := make([]string, len(weights))
+	for i, weight := range weights {
+		formatted[i] = strconv.FormatFloat(weight, 'g', -1, 64)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// parseWeights reads comma or space separated FSRS weights. An empty text
+// means the default weights.
+func parseWeights(text string) ([]float64, error) {
+	fields := strings.Fields(strings.ReplaceAll(text, ",", " "))
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if len(fields) != len(fsrs.DefaultWeights) {
+		return nil, fmt.Errorf("expected %d weights, got %d", len(fsrs.DefaultWeights), len(fields))
+	}
+	weights := make([]float64, len(fields))
+	for i, field := range fields {
+		weight, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("weight %d: %w", i+1, err)
+		}
+		weights[i] = weight
+	}
+	return weights, nil
+}
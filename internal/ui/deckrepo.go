@@ -2,8 +2,6 @@ package ui
 
 import (
 	"memoflash/internal/models"
-
-	"cogentcore.org/core/core"
 )
 
 type deckrepo interface {
@@ -31,9 +29,11 @@ func (app *App) DeleteDeck(id int) {
 	}
 }
 func (app *App) FetchDecks() {
-	list, err := app.Services.GetDecks()
+	ctx, cancel := queryContext(app)
+	defer cancel()
+	list, err := app.Services.GetDecks(ctx)
 	if err != nil {
-		core.ErrorDialog(app, err, "Error Getting Decks")
+		errorSnackbar(app, err, "Error Getting Decks")
 		return
 	}
 	if len(list) == 0 {
@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"image/color"
 	"memoflash/internal/models"
 	"memoflash/internal/services"
 	"memoflash/internal/values"
-	"memoflash/pkg/fsrs"
+	"slices"
 	"strconv"
 	"time"
 
@@ -94,16 +97,20 @@ func (dt *DeckTab) makeDeckHeader(parent tree.Node) {
 				s.Padding.SetAll(units.Dp(12))
 			})
 			w.OnClick(func(e events.Event) {
-				dueCards, err := dt.service.GetAllDueCards()
-				if err != nil {
-					core.ErrorSnackbar(dt, err, "Error Getting Due Cards")
-					return
+				getDueCards := func(ctx context.Context) ([]*models.Card, error) {
+					return dt.service.GetAllDueCards(ctx, Settings.DailyCardLimit)
 				}
-				if len(dueCards) == 0 {
-					core.MessageDialog(dt, "No Due cards to study")
-					return
-				}
-				dt.HandleStudy(dueCards)
+				runQuery(dt, getDueCards, func(dueCards []*models.Card, err error) {
+					if err != nil {
+						errorSnackbar(dt, err, "Error Getting Due Cards")
+						return
+					}
+					if len(dueCards) == 0 {
+						core.MessageDialog(dt, "No Due cards to study")
+						return
+					}
+					dt.HandleStudy(dueCards, true)
+				})
 			})
 		})
 		tree.AddChildAt(w, "deck-create-button", func(w *core.Button) {
@@ -115,9 +122,11 @@ func (dt *DeckTab) makeDeckHeader(parent tree.Node) {
 			w.OnClick(func(e events.Event) {
 				ShowDeckDialog(dt, &DeckData{},
 					false, func(dd *DeckData) {
-						id, err := dt.service.CreateDeck(dd.Title, dd.Description, dd.CategoryColorIndex)
+						ctx, cancel := queryContext(dt)
+						defer cancel()
+						id, err := dt.service.CreateDeck(ctx, dd.Title, dd.Description, dd.CategoryColorIndex)
 						if err != nil {
-							core.ErrorSnackbar(dt, err, "Error Creating Deck")
+							errorSnackbar(dt, err, "Error Creating Deck")
 							return
 						}
 						item := &models.Deck{
@@ -138,18 +147,111 @@ func (dt *DeckTab) makeDeckHeader(parent tree.Node) {
 func (dt *DeckTab) handleActions(w *Deck, deck *models.Deck) {
 	w.OnAddCard(func() {
 		ShowCardDialog(dt, &CardData{}, false, func(card *CardData) {
-			err := dt.service.CreateCard(card.Front, card.Back, deck.ID)
+			if card.Cloze {
+				ctx, cancel := queryContext(dt)
+				defer cancel()
+				cards, err := dt.service.CreateClozeNote(ctx, card.Front, card.Back, deck.ID)
+				if err != nil {
+					errorSnackbar(dt, err, "Error Creating Card")
+					return
+				}
+				deck.TotalCards += len(cards)
+				deck.DueCards += len(cards)
+				w.Update()
+				return
+			}
+			ctx, cancel := queryContext(dt)
+			defer cancel()
+			cards, err := dt.service.CreateCard(ctx, card.Front, card.Back, deck.ID, card.Reversed, card.TypeAnswer)
 			if err != nil {
-				core.ErrorSnackbar(dt, err, "Error Creating Card")
+				errorSnackbar(dt, err, "Error Creating Card")
 				return
 			}
-			deck.TotalCards += 1
-			deck.DueCards += 1
+			deck.TotalCards += len(cards)
+			deck.DueCards += len(cards)
 			w.Update()
 
 		})
 
 	})
+	w.OnAddNote(func() {
+		ctx, cancel := queryContext(dt)
+		defer cancel()
+		noteTypes, err := dt.service.GetNoteTypes(ctx)
+		if err != nil {
+			errorSnackbar(dt, err, "Error Getting Note Types")
+			return
+		}
+		ShowNoteDialog(dt, noteTypes, &NoteData{}, false, func(note *NoteData) {
+			ctx, cancel := queryContext(dt)
+			defer cancel()
+			cards, err := dt.service.CreateNote(ctx, note.NoteTypeID, deck.ID, note.Fields)
+			if err != nil {
+				errorSnackbar(dt, err, "Error Creating Note")
+				return
+			}
+			deck.TotalCards += len(cards)
+			deck.DueCards += len(cards)
+			w.Update()
+		})
+	})
+	w.OnCram(func() {
+		runQuery(dt, func(ctx context.Context) ([]*models.Card, error) {
+			return dt.service.GetCardsByDeck(ctx, deck.ID)
+		}, func(cards []*models.Card, err error) {
+			if err != nil {
+				errorSnackbar(dt, err, "Error Getting Cards")
+				return
+			}
+			cards = slices.DeleteFunc(cards, func(card *models.Card) bool {
+				return card.Suspended
+			})
+			if len(cards) == 0 {
+				core.MessageDialog(dt, "No cards to cram")
+				return
+			}
+			dt.HandleStudy(cards, false)
+		})
+	})
+	w.OnQuiz(func() {
+		runQuery(dt, func(ctx context.Context) ([]*services.QuizQuestion, error) {
+			return dt.service.GetQuiz(ctx, deck.ID)
+		}, func(questions []*services.QuizQuestion, err error) {
+			if err != nil {
+				errorSnackbar(dt, err, "Error Getting Cards")
+				return
+			}
+			if len(questions) == 0 {
+				core.MessageDialog(dt, fmt.Sprintf("No due cards to quiz. A quiz needs due cards and at least %d different answers in the deck", services.QuizOptions))
+				return
+			}
+			dt.HandleQuiz(questions)
+		})
+	})
+	w.OnCustomStudy(func() {
+		ShowCustomStudyDialog(dt, &CustomStudyData{Days: 1, Count: 20, Reschedule: true}, func(cd *CustomStudyData) {
+			study := services.CustomStudy{Mode: cd.Mode, DeckID: deck.ID, Days: cd.Days, Count: cd.Count}
+			if cd.Mode == services.RandomCram && cd.FromTag {
+				study.Tag = cd.Tag
+			}
+			runQuery(dt, func(ctx context.Context) ([]*models.Card, error) {
+				return dt.service.GetCustomStudyCards(ctx, study)
+			}, func(cards []*models.Card, err error) {
+				if err != nil {
+					errorSnackbar(dt, err, "Error Getting Cards")
+					return
+				}
+				if len(cards) == 0 {
+					core.MessageDialog(dt, "No cards match this custom study")
+					return
+				}
+				dt.HandleStudy(cards, cd.Reschedule)
+			})
+		})
+	})
+	w.OnOptions(func() {
+		dt.ShowDeckOptions(deck)
+	})
 	w.OnEdit(func() {
 		ShowDeckDialog(dt, &DeckData{
 			Title:              deck.Title,
@@ -157,9 +259,11 @@ func (dt *DeckTab) handleActions(w *Deck, deck *models.Deck) {
 			CategoryColorIndex: deck.CategoryIndex,
 		},
 			true, func(dd *DeckData) {
-				err := dt.service.EditDeck(deck.ID, dd.Title, dd.Description, dd.CategoryColorIndex)
+				ctx, cancel := queryContext(dt)
+				defer cancel()
+				err := dt.service.EditDeck(ctx, deck.ID, dd.Title, dd.Description, dd.CategoryColorIndex)
 				if err != nil {
-					core.ErrorSnackbar(dt, err, "Error Updating Deck")
+					errorSnackbar(dt, err, "Error Updating Deck")
 					return
 				}
 				deck.Title = dd.Title
@@ -201,10 +305,14 @@ func (dt *DeckTab) handleActions(w *Deck, deck *models.Deck) {
 
 	w.OnDelete(func() {
 		deletAction := func() {
-			err := dt.service.DeleteDeck(deck.ID)
+			ctx, cancel := queryContext(dt)
+			defer cancel()
+			err := dt.service.DeleteDeck(ctx, deck.ID)
 			if err != nil {
-				core.ErrorSnackbar(dt, err, "Error Deleting Deck")
-				return
+				errorSnackbar(dt, err, "Error Deleting Deck")
+				if !errors.Is(err, services.ErrNotFound) {
+					return
+				}
 			}
 			dt.deckrepo.DeleteDeck(deck.ID)
 			dt.UpdateList()
@@ -223,25 +331,41 @@ func (dt *DeckTab) handleActions(w *Deck, deck *models.Deck) {
 			core.MessageDialog(dt, "No cards to study")
 			return
 		}
-		dueCards, err := dt.service.GetDueCardsFromDeck(deck.ID)
-		if err != nil {
-			core.ErrorSnackbar(dt, err, "Error Getting Due Cards")
-			return
-		}
-		if len(dueCards) == 0 {
-			core.MessageDialog(dt, "No cards to study")
-			return
-		}
-		dt.HandleStudy(dueCards)
+		runQuery(dt, func(ctx context.Context) ([]*models.Card, error) {
+			return dt.service.GetDueCardsFromDeck(ctx, deck.ID)
+		}, func(dueCards []*models.Card, err error) {
+			if err != nil {
+				errorSnackbar(dt, err, "Error Getting Due Cards")
+				return
+			}
+			if len(dueCards) == 0 {
+				core.MessageDialog(dt, "No cards to study")
+				return
+			}
+			dt.HandleStudy(dueCards, true)
+		})
 
 	})
 
 }
-func (dt *DeckTab) HandleStudy(dueCards []*models.Card) {
+
+// HandleStudy runs a study session over dueCards. Answers only reschedule
+// the cards when reschedule is set; otherwise the session is a cram that
+// leaves the scheduling state alone and brings cards rated Again back at the
+// end until each one has been answered correctly. In a regular session a
+// card whose learning step makes it due again today comes back at the end
+// of the queue as well.
+func (dt *DeckTab) HandleStudy(dueCards []*models.Card, reschedule bool) {
 	d := core.NewBody("Back to Decks")
 	pages := core.NewPages(d)
 	same := true
 	deckid := dueCards[0].ParentDeckId
+	mode := values.StudySession
+	if !reschedule {
+		mode = values.CramSession
+	}
+	summary := NewSessionSummary(mode)
+	var session *models.StudySession
 
 	pages.AddPage("main", func(pg *core.Pages) {
 		p := core.NewFrame(pg)
@@ -251,29 +375,173 @@ func (dt *DeckTab) HandleStudy(dueCards []*models.Card) {
 		})
 		tree.AddChild(p, func(w *StudyPage) {
 			w.Cards = dueCards
-			w.OnEach = func(card *models.Card, rating values.Difficulty) error {
-				fsrs.Review(rating, card)
+			w.OnEach = func(card *models.Card, rating values.Difficulty, duration time.Duration) error {
 				if card.ParentDeckId != deckid {
 					same = false
 				}
-				err := dt.service.UpdateInterval(card.ID, card.Interval, card.Difficulty, card.Stability)
+				deckid = card.ParentDeckId
+				summary.Record(card, rating)
+				if !reschedule {
+					if rating == values.Again {
+						w.Cards = append(w.Cards, card)
+					}
+					return nil
+				}
+				wasDue := card.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow())
+				ctx, cancel := queryContext(w)
+				defer cancel()
+				leech, err := dt.service.ReviewCard(ctx, card, rating, duration)
 				if err != nil {
 					return err
 				}
-				if deck := dt.deckrepo.GetDeck(card.ParentDeckId); deck != nil {
+				if leech {
+					message := "This card is a leech. Consider rewriting it"
+					if card.Suspended {
+						message = "This card is a leech and has been suspended"
+					}
+					core.MessageSnackbar(w, message)
+				}
+				if card.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow()) {
+					// The card stays due, so the deck's count stays as it is.
+					w.Cards = append(w.Cards, card)
+				} else if deck := dt.deckrepo.GetDeck(card.ParentDeckId); deck != nil && wasDue {
 					deck.DueCards--
 				}
-				deckid = card.ParentDeckId
-
 				return nil
 			}
+			w.OnUndo = func(card *models.Card, before *models.Card, rating values.Difficulty) error {
+				if reschedule {
+					requeued := card.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow())
+					ctx, cancel := queryContext(w)
+					defer cancel()
+					if err := dt.service.UndoReview(ctx, before); err != nil {
+						return err
+					}
+					if requeued {
+						w.Cards = w.Cards[:len(w.Cards)-1]
+					} else if deck := dt.deckrepo.GetDeck(card.ParentDeckId); deck != nil && before.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow()) {
+						deck.DueCards++
+					}
+				} else if rating == values.Again {
+					// Drop the repeat queued for the undone answer.
+					w.Cards = w.Cards[:len(w.Cards)-1]
+				}
+				summary.Unrecord(before, rating)
+				return nil
+			}
+			removeDue := func(card *models.Card, wasDue bool) {
+				if deck := dt.deckrepo.GetDeck(card.ParentDeckId); deck != nil && wasDue {
+					deck.DueCards--
+				}
+			}
+			w.OnSuspend = func(card *models.Card) error {
+				wasDue := card.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow())
+				ctx, cancel := queryContext(w)
+				defer cancel()
+				if err := dt.service.SuspendCard(ctx, card.ID, true); err != nil {
+					return err
+				}
+				card.Suspended = true
+				removeDue(card, wasDue)
+				return nil
+			}
+			w.OnBury = func(card *models.Card) error {
+				wasDue := card.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow())
+				ctx, cancel := queryContext(w)
+				defer cancel()
+				if err := dt.service.BuryCard(ctx, card.ID, true); err != nil {
+					return err
+				}
+				card.BuriedUntil = dt.service.Calendar.Tomorrow()
+				removeDue(card, wasDue)
+				return nil
+			}
+			w.OnEdit = func(card *models.Card, onSaved func(saved, added []*models.Card)) {
+				EditCardDialog(w, dt.service, card, onSaved)
+			}
+			w.OnFlag = func(card *models.Card, flag values.Flag) error {
+				ctx, cancel := queryContext(w)
+				defer cancel()
+				return dt.service.FlagCard(ctx, card.ID, flag)
+			}
 			w.OnDone = func() {
 				if same {
-					dt.service.UpdateReadTime(deckid)
-					if deck := dt.deckrepo.GetDeck(deckid); deck != nil {
-						deck.LastStudied = time.Now()
+					ctx, cancel := queryContext(w)
+					defer cancel()
+					if err := dt.service.UpdateReadTime(ctx, deckid); err != nil {
+						errorSnackbar(w, err, "Error Updating Deck")
+					} else if deck := dt.deckrepo.GetDeck(deckid); deck != nil {
+						deck.LastStudied = dt.service.Calendar.Now()
 					}
+				} else {
+					deckid = 0
+				}
+				session = dt.saveSession(summary, deckid)
+				pages.Open("status-page")
+			}
+		})
+	})
+
+	pages.AddPage("status-page", func(pg *core.Pages) {
+		fr := core.NewFrame(pg)
+		fr.Styler(func(s *styles.Style) {
+			s.CenterAll()
+			s.Grow.Set(1, 1)
+		})
+		NewSummaryPage(fr, dt.service, session, summary.Lapsed)
+	})
+
+	d.OnClose(func(e events.Event) {
+		dt.UpdateList()
+	})
+
+	d.RunFullDialog(dt)
+}
+
+// HandleQuiz runs a multiple choice session over questions. Answers
+// reschedule the cards like a regular study session.
+func (dt *DeckTab) HandleQuiz(questions []*services.QuizQuestion) {
+	d := core.NewBody("Back to Decks")
+	pages := core.NewPages(d)
+	summary := NewSessionSummary(values.QuizSession)
+	deckid := questions[0].Card.ParentDeckId
+	var session *models.StudySession
+
+	pages.AddPage("main", func(pg *core.Pages) {
+		p := core.NewFrame(pg)
+		p.Styler(func(s *styles.Style) {
+			s.Grow.Set(1, 1)
+			s.CenterAll()
+		})
+		tree.AddChild(p, func(w *QuizPage) {
+			w.Questions = questions
+			w.OnEach = func(card *models.Card, rating values.Difficulty, duration time.Duration) error {
+				summary.Record(card, rating)
+				wasDue := card.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow())
+				ctx, cancel := queryContext(w)
+				defer cancel()
+				leech, err := dt.service.ReviewCard(ctx, card, rating, duration)
+				if err != nil {
+					return err
+				}
+				if leech {
+					core.MessageSnackbar(w, "This card is a leech. Consider rewriting it")
+				}
+				stillDue := card.IsDue(dt.service.Calendar.Now(), dt.service.Calendar.Tomorrow())
+				if deck := dt.deckrepo.GetDeck(card.ParentDeckId); deck != nil && wasDue && !stillDue {
+					deck.DueCards--
 				}
+				return nil
+			}
+			w.OnDone = func() {
+				ctx, cancel := queryContext(w)
+				defer cancel()
+				if err := dt.service.UpdateReadTime(ctx, deckid); err != nil {
+					errorSnackbar(w, err, "Error Updating Deck")
+				} else if deck := dt.deckrepo.GetDeck(deckid); deck != nil {
+					deck.LastStudied = dt.service.Calendar.Now()
+				}
+				session = dt.saveSession(summary, deckid)
 				pages.Open("status-page")
 			}
 		})
@@ -285,7 +553,7 @@ func (dt *DeckTab) HandleStudy(dueCards []*models.Card) {
 			s.CenterAll()
 			s.Grow.Set(1, 1)
 		})
-		StatePage(fr)
+		NewSummaryPage(fr, dt.service, session, summary.Lapsed)
 	})
 
 	d.OnClose(func(e events.Event) {
@@ -295,6 +563,21 @@ func (dt *DeckTab) HandleStudy(dueCards []*models.Card) {
 	d.RunFullDialog(dt)
 }
 
+// saveSession stores the record of a finished session so it shows up in the
+// session history, and returns it.
+func (dt *DeckTab) saveSession(summary *SessionSummary, deckId int) *models.StudySession {
+	session := summary.Session(deckId)
+	if session.Answers == 0 {
+		return session
+	}
+	ctx, cancel := queryContext(dt)
+	defer cancel()
+	if err := dt.service.SaveSession(ctx, session); err != nil {
+		errorSnackbar(dt, err, "Error Saving Session")
+	}
+	return session
+}
+
 func (dt *DeckTab) makeDeckList(p *tree.Plan, items []*models.Deck) {
 	for _, deck := range items {
 		tree.AddAt(p, strconv.Itoa(deck.ID), func(w *Deck) {
@@ -1,21 +1,49 @@
 package ui
 
 import (
+	"fmt"
+	"memoflash/internal/models"
+	"memoflash/internal/services"
+	"memoflash/pkg/cloze"
+	"slices"
+	"strconv"
 	"strings"
 
 	"cogentcore.org/core/colors"
 	"cogentcore.org/core/core"
 	"cogentcore.org/core/events"
+	"cogentcore.org/core/icons"
 	"cogentcore.org/core/styles"
 	"cogentcore.org/core/styles/states"
 	"cogentcore.org/core/styles/units"
 	"cogentcore.org/core/text/rich"
+	"cogentcore.org/core/text/textcore"
+	"cogentcore.org/core/tree"
 )
 
 type CardData struct {
 	Front    string
 	Back     string
-	KeepOpen bool
+	Cloze    bool
+	Reversed bool
+	// TypeAnswer asks for the back to be typed when studying.
+	TypeAnswer bool
+	KeepOpen   bool
+}
+type NoteData struct {
+	NoteTypeID int
+	Fields     map[string]string
+	KeepOpen   bool
+}
+type CustomStudyData struct {
+	Mode services.CustomStudyMode
+	Days int
+	// Count is the number of cards for ExtraNew and RandomCram sessions.
+	Count int
+	// FromTag crams cards carrying Tag instead of the deck's cards.
+	FromTag    bool
+	Tag        string
+	Reschedule bool
 }
 type DeckData struct {
 	Title              string
@@ -32,24 +60,89 @@ func ShowCardDialog(ctx core.Widget, data *CardData, isEdit bool, onAccept func(
 	d := core.NewBody(title)
 	core.NewText(d).SetType(core.TextBodyMedium).SetText(title)
 
-	core.NewText(d).SetText("Front").Styler(func(s *styles.Style) {
+	if !isEdit {
+		clozeSwitch := core.NewSwitch(d).SetText("Cloze")
+		clozeSwitch.SetChecked(data.Cloze)
+		clozeSwitch.OnChange(func(e events.Event) {
+			data.Cloze = clozeSwitch.IsChecked()
+			d.Update()
+		})
+	}
+
+	frontLabel := core.NewText(d)
+	frontLabel.Styler(func(s *styles.Style) {
 		s.Font.Weight = rich.Bold
 	})
+	frontLabel.Updater(func() {
+		if data.Cloze {
+			frontLabel.SetText("Text")
+		} else {
+			frontLabel.SetText("Front")
+		}
+	})
 
 	frontField := core.NewTextField(d).SetPlaceholder("Enter front text")
 	frontField.SetType(core.TextFieldOutlined)
 	frontField.Styler(func(s *styles.Style) {
 		s.Grow.Set(1, 0)
 		s.Max.Zero()
+		if data.Cloze {
+			s.Display = styles.DisplayNone
+		}
 	})
 	frontField.SetText(data.Front)
 	frontField.OnChange(func(e events.Event) {
 		data.Front = frontField.Text()
 	})
 
-	core.NewText(d).SetText("Back").Styler(func(s *styles.Style) {
+	clozeEditor := textcore.NewEditor(d)
+	clozeEditor.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 0)
+		s.Min.Y.Em(5)
+		if !data.Cloze {
+			s.Display = styles.DisplayNone
+		}
+	})
+	clozeEditor.Lines.SetString(data.Front)
+	clozeText := func() string {
+		return strings.TrimSuffix(clozeEditor.Lines.String(), "\n")
+	}
+
+	// updateButton refreshes the state of the create button; it is set once
+	// the button is made.
+	var updateButton func()
+
+	wrapButton := core.NewButton(d).SetText("Wrap selection in cloze").SetIcon(icons.DataArray)
+	wrapButton.SetType(core.ButtonTonal)
+	wrapButton.SetShortcut("Command+Shift+C")
+	wrapButton.Styler(func(s *styles.Style) {
+		if !data.Cloze {
+			s.Display = styles.DisplayNone
+		}
+	})
+	wrapButton.OnClick(func(e events.Event) {
+		number := cloze.NextNumber(clozeText())
+		selection := ""
+		if sel := clozeEditor.Selection(); sel != nil {
+			selection = string(sel.ToBytes())
+		}
+		clozeEditor.InsertAtCursor([]byte(cloze.Wrap(selection, number)))
+		clozeEditor.SetFocus()
+		data.Front = clozeText()
+		updateButton()
+	})
+
+	backLabel := core.NewText(d)
+	backLabel.Styler(func(s *styles.Style) {
 		s.Font.Weight = rich.Bold
 	})
+	backLabel.Updater(func() {
+		if data.Cloze {
+			backLabel.SetText("Extra")
+		} else {
+			backLabel.SetText("Back")
+		}
+	})
 
 	backField := core.NewTextField(d).SetPlaceholder("Enter back text")
 	backField.Styler(func(s *styles.Style) {
@@ -61,7 +154,31 @@ func ShowCardDialog(ctx core.Widget, data *CardData, isEdit bool, onAccept func(
 		data.Back = backField.Text()
 	})
 
+	typeAnswerSwitch := core.NewSwitch(d).SetText("Type the answer")
+	typeAnswerSwitch.SetTooltip("Type the back into a field before it is revealed")
+	typeAnswerSwitch.SetChecked(data.TypeAnswer)
+	typeAnswerSwitch.Styler(func(s *styles.Style) {
+		if data.Cloze {
+			s.Display = styles.DisplayNone
+		}
+	})
+	typeAnswerSwitch.OnChange(func(e events.Event) {
+		data.TypeAnswer = typeAnswerSwitch.IsChecked()
+	})
+
 	if !isEdit {
+		reversedSwitch := core.NewSwitch(d).SetText("Reversed")
+		reversedSwitch.SetTooltip("Also create a card with the sides swapped")
+		reversedSwitch.SetChecked(data.Reversed)
+		reversedSwitch.Styler(func(s *styles.Style) {
+			if data.Cloze {
+				s.Display = styles.DisplayNone
+			}
+		})
+		reversedSwitch.OnChange(func(e events.Event) {
+			data.Reversed = reversedSwitch.IsChecked()
+		})
+
 		var keepOpenSwitch *core.Switch
 
 		keepOpenSwitch = core.NewSwitch(d).SetText("Keep Open")
@@ -79,8 +196,12 @@ func ShowCardDialog(ctx core.Widget, data *CardData, isEdit bool, onAccept func(
 		} else {
 			create.SetText("Create")
 		}
-		updateButton := func() {
-			isDisabled = len(strings.TrimSpace(frontField.Text())) == 0 || len(strings.TrimSpace(backField.Text())) == 0
+		updateButton = func() {
+			if data.Cloze {
+				isDisabled = len(cloze.Numbers(clozeText())) == 0
+			} else {
+				isDisabled = len(strings.TrimSpace(frontField.Text())) == 0 || len(strings.TrimSpace(backField.Text())) == 0
+			}
 			create.Update()
 		}
 
@@ -93,23 +214,31 @@ func ShowCardDialog(ctx core.Widget, data *CardData, isEdit bool, onAccept func(
 		backField.OnInput(func(e events.Event) {
 			updateButton()
 		})
+		clozeEditor.OnInput(func(e events.Event) {
+			data.Front = clozeText()
+			updateButton()
+		})
+		d.OnShow(func(e events.Event) {
+			updateButton()
+		})
 
-		if isEdit {
-			create.SetText("Save")
-		} else {
-			create.SetText("Create")
-		}
 		create.OnClick(func(e events.Event) {
 			if onAccept != nil {
+				if data.Cloze {
+					data.Front = clozeText()
+				}
+				accepted := *data
 				if data.KeepOpen {
 					backField.SetText("")
 					frontField.SetText("")
+					clozeEditor.Lines.SetString("")
+					data.Front, data.Back = "", ""
 					isDisabled = true
 					create.Update()
 				} else {
 					d.Close()
 				}
-				onAccept(data)
+				onAccept(&accepted)
 			}
 
 		})
@@ -121,6 +250,130 @@ func ShowCardDialog(ctx core.Widget, data *CardData, isEdit bool, onAccept func(
 	dialog.Run()
 }
 
+func ShowNoteDialog(ctx core.Widget, noteTypes []*models.NoteType, data *NoteData, isEdit bool, onAccept func(*NoteData)) {
+	title := "Create a new note"
+	if isEdit {
+		title = "Edit your note"
+	}
+	if data.Fields == nil {
+		data.Fields = make(map[string]string)
+	}
+	noteTypeIndex := slices.IndexFunc(noteTypes, func(nt *models.NoteType) bool {
+		return nt.ID == data.NoteTypeID
+	})
+	if noteTypeIndex < 0 {
+		noteTypeIndex = 0
+		data.NoteTypeID = noteTypes[0].ID
+	}
+	d := core.NewBody(title)
+	core.NewText(d).SetType(core.TextBodyMedium).SetText(title)
+
+	var fieldsFrame *core.Frame
+	var create *core.Button
+	isDisabled := func() bool {
+		for _, field := range noteTypes[noteTypeIndex].Fields {
+			if len(strings.TrimSpace(data.Fields[field])) > 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !isEdit {
+		core.NewText(d).SetText("Type").Styler(func(s *styles.Style) {
+			s.Font.Weight = rich.Bold
+		})
+		typeChooser := core.NewChooser(d)
+		for _, noteType := range noteTypes {
+			typeChooser.Items = append(typeChooser.Items, core.ChooserItem{Value: noteType.ID, Text: noteType.Name})
+		}
+		typeChooser.SetCurrentIndex(noteTypeIndex)
+		typeChooser.OnChange(func(e events.Event) {
+			noteTypeIndex = typeChooser.CurrentIndex
+			data.NoteTypeID = noteTypes[noteTypeIndex].ID
+			fieldsFrame.Update()
+			create.Update()
+		})
+	}
+
+	fieldsFrame = core.NewFrame(d)
+	fieldsFrame.Styler(func(s *styles.Style) {
+		s.Direction = styles.Column
+		s.Grow.Set(1, 0)
+		s.Padding.Zero()
+	})
+	fieldsFrame.Maker(func(p *tree.Plan) {
+		noteType := noteTypes[noteTypeIndex]
+		for _, field := range noteType.Fields {
+			tree.AddAt(p, strconv.Itoa(noteType.ID)+"-"+field+"-label", func(w *core.Text) {
+				w.SetText(field).Styler(func(s *styles.Style) {
+					s.Font.Weight = rich.Bold
+				})
+			})
+			tree.AddAt(p, strconv.Itoa(noteType.ID)+"-"+field, func(w *core.TextField) {
+				w.SetPlaceholder("Enter " + strings.ToLower(field))
+				w.Styler(func(s *styles.Style) {
+					s.Grow.Set(1, 0)
+					s.Max.Zero()
+				})
+				w.Updater(func() {
+					w.SetText(data.Fields[field])
+				})
+				w.OnInput(func(e events.Event) {
+					data.Fields[field] = w.Text()
+					create.Update()
+				})
+				w.OnChange(func(e events.Event) {
+					data.Fields[field] = w.Text()
+				})
+			})
+		}
+	})
+
+	if !isEdit {
+		keepOpenSwitch := core.NewSwitch(d).SetText("Keep Open")
+		keepOpenSwitch.SetChecked(data.KeepOpen)
+		keepOpenSwitch.OnChange(func(e events.Event) {
+			data.KeepOpen = keepOpenSwitch.IsChecked()
+		})
+	}
+
+	d.AddBottomBar(func(bar *core.Frame) {
+		d.AddCancel(bar)
+		create = core.NewButton(bar)
+		if isEdit {
+			create.SetText("Save")
+		} else {
+			create.SetText("Create")
+		}
+		create.Updater(func() {
+			create.SetState(isDisabled(), states.Disabled)
+		})
+		create.OnClick(func(e events.Event) {
+			if onAccept == nil {
+				return
+			}
+			accepted := &NoteData{NoteTypeID: data.NoteTypeID, Fields: make(map[string]string)}
+			for _, field := range noteTypes[noteTypeIndex].Fields {
+				accepted.Fields[field] = data.Fields[field]
+			}
+			if data.KeepOpen {
+				clear(data.Fields)
+				fieldsFrame.Update()
+				create.Update()
+			} else {
+				d.Close()
+			}
+			onAccept(accepted)
+		})
+	})
+
+	dialog := d.NewDialog(ctx)
+	dialog.SetDisplayTitle(true)
+	dialog.SetResizable(false)
+	dialog.Run()
+}
+
 func ShowDeckDialog(ctx core.Widget, data *DeckData, isEdit bool, onAccept func(*DeckData)) {
 	isDisabled := !isEdit
 	title := "Create a new deck"
@@ -225,6 +478,38 @@ func ShowDeckDialog(ctx core.Widget, data *DeckData, isEdit bool, onAccept func(
 	dialog.Run()
 
 }
+
+// DeleteCardDialog asks whether to delete only the card or the card together
+// with the siblings generated from the same note.
+func DeleteCardDialog(ctx core.Widget, siblings int, onDeleteCard func(), onDeleteAll func()) {
+	dialog := core.NewBody("Delete Card ?")
+	core.NewText(dialog).SetText(fmt.Sprintf("This card has %d linked sibling card(s). this action is irreversible", siblings))
+	dialog.AddBottomBar(func(bar *core.Frame) {
+		dialog.AddCancel(bar)
+		core.NewButton(bar).SetType(core.ButtonOutlined).SetText("Delete This Card").OnClick(func(e events.Event) {
+			dialog.Close()
+			if onDeleteCard != nil {
+				onDeleteCard()
+			}
+		})
+		btn := dialog.AddOK(bar)
+		btn.SetText("Delete Both")
+		btn.Styler(func(s *styles.Style) {
+			s.Color = colors.Uniform(colors.White)
+			s.Background = colors.Uniform(colors.Red)
+		})
+		btn.OnClick(func(e events.Event) {
+			dialog.Close()
+			if onDeleteAll != nil {
+				onDeleteAll()
+			}
+		})
+	})
+	d := dialog.NewDialog(ctx)
+	d.SetResizable(false)
+	d.Run()
+}
+
 func WarningDialog(ctx core.Widget, title, message string, buttonText string, onYes func()) {
 	dialog := core.NewBody(title)
 	core.NewText(dialog).SetText(message)
@@ -247,3 +532,106 @@ func WarningDialog(ctx core.Widget, title, message string, buttonText string, on
 	d.SetResizable(false)
 	d.Run()
 }
+
+// ShowCustomStudyDialog asks how to build a custom study session.
+func ShowCustomStudyDialog(ctx core.Widget, data *CustomStudyData, onAccept func(*CustomStudyData)) {
+	d := core.NewBody("Custom study")
+	core.NewText(d).SetType(core.TextBodyMedium).SetText("Study cards outside the daily queue")
+
+	modeChooser := core.NewChooser(d).SetStrings(services.CustomStudyModeNames...)
+	modeChooser.SetCurrentIndex(int(data.Mode))
+	modeChooser.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 0)
+	})
+
+	daysText := core.NewText(d)
+	daysText.Updater(func() {
+		if data.Mode == services.Forgotten {
+			daysText.SetText("Failed in the last days")
+		} else {
+			daysText.SetText("Due in the next days")
+		}
+	})
+	daysSpinner := core.NewSpinner(d).SetMin(1).SetMax(365).SetStep(1)
+	daysSpinner.SetValue(float32(data.Days))
+	daysSpinner.OnChange(func(e events.Event) {
+		data.Days = int(daysSpinner.Value)
+	})
+	for _, w := range []core.Widget{daysText, daysSpinner} {
+		w.AsWidget().Styler(func(s *styles.Style) {
+			if data.Mode != services.ReviewAhead && data.Mode != services.Forgotten {
+				s.Display = styles.DisplayNone
+			}
+		})
+	}
+
+	countText := core.NewText(d).SetText("Number of cards")
+	countSpinner := core.NewSpinner(d).SetMin(1).SetMax(9999).SetStep(1)
+	countSpinner.SetValue(float32(data.Count))
+	countSpinner.OnChange(func(e events.Event) {
+		data.Count = int(countSpinner.Value)
+	})
+	for _, w := range []core.Widget{countText, countSpinner} {
+		w.AsWidget().Styler(func(s *styles.Style) {
+			if data.Mode != services.ExtraNew && data.Mode != services.RandomCram {
+				s.Display = styles.DisplayNone
+			}
+		})
+	}
+
+	tagSwitch := core.NewSwitch(d).SetText("Cram cards with a tag instead of this deck")
+	tagSwitch.SetChecked(data.FromTag)
+	tagField := core.NewTextField(d).SetPlaceholder("Tag")
+	tagField.SetText(data.Tag)
+	tagField.OnChange(func(e events.Event) {
+		data.Tag = strings.TrimSpace(tagField.Text())
+	})
+	tagSwitch.Styler(func(s *styles.Style) {
+		if data.Mode != services.RandomCram {
+			s.Display = styles.DisplayNone
+		}
+	})
+	tagField.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 0)
+		s.Max.Zero()
+		if data.Mode != services.RandomCram || !data.FromTag {
+			s.Display = styles.DisplayNone
+		}
+	})
+	tagSwitch.OnChange(func(e events.Event) {
+		data.FromTag = tagSwitch.IsChecked()
+		d.Update()
+	})
+
+	rescheduleSwitch := core.NewSwitch(d).SetText("Reschedule cards based on my answers")
+	rescheduleSwitch.SetChecked(data.Reschedule)
+	rescheduleSwitch.OnChange(func(e events.Event) {
+		data.Reschedule = rescheduleSwitch.IsChecked()
+	})
+
+	modeChooser.OnChange(func(e events.Event) {
+		data.Mode = services.CustomStudyMode(modeChooser.CurrentIndex)
+		d.Update()
+	})
+
+	d.AddBottomBar(func(bar *core.Frame) {
+		d.AddCancel(bar)
+		start := d.AddOK(bar).SetText("Start")
+		start.Updater(func() {
+			start.SetState(data.Mode == services.RandomCram && data.FromTag && data.Tag == "", states.Disabled)
+		})
+		tagField.OnInput(func(e events.Event) {
+			data.Tag = strings.TrimSpace(tagField.Text())
+			start.Update()
+		})
+		start.OnClick(func(e events.Event) {
+			if onAccept != nil {
+				onAccept(data)
+			}
+		})
+	})
+	dialog := d.NewDialog(ctx)
+	dialog.SetDisplayTitle(true)
+	dialog.SetResizable(false)
+	dialog.Run()
+}
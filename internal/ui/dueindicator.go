@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"memoflash/internal/services"
+	"time"
+
+	"cogentcore.org/core/core"
+)
+
+// dueRefreshInterval is how often the number of due cards is counted for
+// the indicator.
+const dueRefreshInterval = time.Minute
+
+// DueIndicator shows the number of due cards while the app is out of sight.
+// The tray icon, see trayIndicator, also opens the Study tab when clicked.
+type DueIndicator interface {
+	SetDue(due int)
+}
+
+// badgeIndicator is the DueIndicator of the app's own window: a badge on
+// the Study tab, and the count in the window title, which shows in the
+// taskbar while the window is minimised.
+type badgeIndicator struct {
+	app *App
+	tab *core.Tab
+}
+
+func newBadgeIndicator(app *App, tab *core.Tab) *badgeIndicator {
+	return &badgeIndicator{app: app, tab: tab}
+}
+
+func (b *badgeIndicator) SetDue(due int) {
+	label, title := "Study", "MemoFlash"
+	if due > 0 {
+		label = fmt.Sprintf("Study (%d)", due)
+		title = fmt.Sprintf("MemoFlash (%d due)", due)
+	}
+	b.tab.SetText(label).Update()
+	if w := b.app.Scene.RenderWindow(); w != nil && w.SystemWindow != nil {
+		w.SystemWindow.SetTitle(title)
+	}
+}
+
+// startDueIndicator keeps the badge and the tray icon up to date with the
+// number of due cards until ctx is done.
+func (app *App) startDueIndicator(ctx context.Context) {
+	indicators := []DueIndicator{
+		newBadgeIndicator(app, app.studyTab),
+		newTrayIndicator(ctx, app, app.openStudy),
+	}
+	watcher := services.NewDueWatcher(app.Services.CardService)
+	watcher.OnChange(func(due int) {
+		app.AsyncLock()
+		defer app.AsyncUnlock()
+		for _, indicator := range indicators {
+			indicator.SetDue(due)
+		}
+	})
+	go watcher.Run(ctx, dueRefreshInterval, func(err error) {
+		app.AsyncLock()
+		defer app.AsyncUnlock()
+		errorSnackbar(app, err, "Error Counting Due Cards")
+	})
+}
+
+// openStudy brings the window to the front on the Study tab.
+func (app *App) openStudy() {
+	app.Tabs.SelectTabByName("Study")
+	if w := app.Scene.RenderWindow(); w != nil {
+		w.Raise()
+	}
+}
+
+// minimize iconifies the window, leaving the indicator in sight.
+func (app *App) minimize() {
+	if w := app.Scene.RenderWindow(); w != nil && w.SystemWindow != nil {
+		w.SystemWindow.Minimize()
+	}
+}
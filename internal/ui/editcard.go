@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"memoflash/internal/models"
+	"memoflash/internal/services"
+	"slices"
+
+	"cogentcore.org/core/core"
+)
+
+// EditCardDialog opens the editor matching card: the card dialog for plain
+// cards, or the note dialog for cards generated from a note. Every view that
+// edits cards goes through it. card is updated
+// with the saved content before onSaved runs with every card the edit
+// rewrote, which includes the siblings of a note card, and with those of
+// them the edit added to the note.
+func EditCardDialog(ctx core.Widget, service *services.Service, card *models.Card, onSaved func(saved, added []*models.Card)) {
+	if card.NoteID == 0 {
+		ShowCardDialog(ctx, &CardData{
+			Front:      card.Front,
+			Back:       card.Back,
+			TypeAnswer: card.TypeAnswer,
+		}, true, func(cd *CardData) {
+			callCtx, cancel := queryContext(ctx)
+			defer cancel()
+			if err := service.EditCard(callCtx, card.ID, cd.Front, cd.Back, cd.TypeAnswer); err != nil {
+				errorSnackbar(ctx, err, "Error Editing Card")
+				return
+			}
+			card.Front = cd.Front
+			card.Back = cd.Back
+			card.TypeAnswer = cd.TypeAnswer
+			if onSaved != nil {
+				onSaved([]*models.Card{card}, nil)
+			}
+		})
+		return
+	}
+
+	callCtx, cancel := queryContext(ctx)
+	defer cancel()
+	note, err := service.GetNote(callCtx, card.NoteID)
+	if err != nil {
+		errorSnackbar(ctx, err, "Error Getting Note")
+		return
+	}
+	noteTypes, err := service.GetNoteTypes(callCtx)
+	if err != nil {
+		errorSnackbar(ctx, err, "Error Getting Note Types")
+		return
+	}
+	onSave := func(fields map[string]string) {
+		callCtx, cancel := queryContext(ctx)
+		defer cancel()
+		deckCards, err := service.GetCardsByDeck(callCtx, note.DeckID)
+		if err != nil {
+			errorSnackbar(ctx, err, "Error Editing Note")
+			return
+		}
+		cards, err := service.EditNote(callCtx, note.ID, fields)
+		if err != nil {
+			errorSnackbar(ctx, err, "Error Editing Note")
+			return
+		}
+		var added []*models.Card
+		for _, saved := range cards {
+			if !slices.ContainsFunc(deckCards, func(existing *models.Card) bool { return existing.ID == saved.ID }) {
+				added = append(added, saved)
+			}
+		}
+		if i := slices.IndexFunc(cards, func(saved *models.Card) bool { return saved.ID == card.ID }); i >= 0 {
+			card.Front = cards[i].Front
+			card.Back = cards[i].Back
+		}
+		if onSaved != nil {
+			onSaved(cards, added)
+		}
+	}
+	i := slices.IndexFunc(noteTypes, func(noteType *models.NoteType) bool { return noteType.ID == note.NoteTypeID })
+	if i >= 0 && noteTypes[i].Kind == models.ClozeNote {
+		ShowCardDialog(ctx, &CardData{Front: note.Fields["Text"], Back: note.Fields["Extra"], Cloze: true}, true, func(cd *CardData) {
+			onSave(map[string]string{"Text": cd.Front, "Extra": cd.Back})
+		})
+		return
+	}
+	ShowNoteDialog(ctx, noteTypes, &NoteData{NoteTypeID: note.NoteTypeID, Fields: note.Fields}, true, func(nd *NoteData) {
+		onSave(nd.Fields)
+	})
+}
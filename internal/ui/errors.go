@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"memoflash/internal/services"
+	"strings"
+	"unicode"
+
+	"cogentcore.org/core/core"
+)
+
+// errorSnackbar reports err under label like core.ErrorSnackbar. The typed
+// service errors are explained in plain words instead of showing the
+// underlying database error.
+func errorSnackbar(ctx core.Widget, err error, label string) {
+	var recordErr *services.RecordError
+	if !errors.As(err, &recordErr) {
+		core.ErrorSnackbar(ctx, err, label)
+		return
+	}
+	var msg string
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		msg = fmt.Sprintf("the %s no longer exists", recordErr.Entity)
+	case errors.Is(err, services.ErrConflict):
+		msg = fmt.Sprintf("the %s clashes with an existing one", recordErr.Entity)
+		if len(recordErr.Fields) > 0 {
+			fields := make([]string, len(recordErr.Fields))
+			for i, field := range recordErr.Fields {
+				fields[i] = fieldName(field)
+			}
+			msg = fmt.Sprintf("a %s with the same %s already exists", recordErr.Entity, strings.Join(fields, " and "))
+		}
+	case recordErr.Err != nil:
+		msg = fmt.Sprintf("invalid %s, %v", recordErr.Entity, recordErr.Err)
+	default:
+		msg = fmt.Sprintf("invalid %s", recordErr.Entity)
+	}
+	core.MessageSnackbar(ctx, label+": "+msg)
+}
+
+// fieldName turns a column name such as "NoteTypeId" into words for a
+// message: "note type".
+func fieldName(column string) string {
+	var words []string
+	start := 0
+	for i, r := range column {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(column[i-1])) {
+			words = append(words, column[start:i])
+			start = i
+		}
+	}
+	words = append(words, column[start:])
+	if len(words) > 1 && strings.EqualFold(words[len(words)-1], "id") {
+		words = words[:len(words)-1]
+	}
+	return strings.ToLower(strings.Join(words, " "))
+}
@@ -1,11 +1,16 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"memoflash/internal/models"
 	"memoflash/internal/services"
+	"memoflash/internal/values"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"cogentcore.org/core/core"
 	"cogentcore.org/core/events"
@@ -16,9 +21,12 @@ import (
 
 type ExploreView struct {
 	core.Frame
-	deck          *models.Deck
-	service       *services.Service
-	searchQuery   string
+	deck        *models.Deck
+	service     *services.Service
+	searchQuery string
+	// view is the query of the filtered view picked next to the search
+	// field; it is combined with searchQuery.
+	view          string
 	Cards         []*models.Card
 	deckListFrame *core.Frame
 	contentFrame  *core.Frame
@@ -32,30 +40,53 @@ func (ev *ExploreView) Init() {
 		s.Grow.Set(1, 1)
 	})
 	ev.Updater(func() {
-		if ev.Cards == nil {
-			cards, err := ev.service.GetCardsByDeck(ev.deck.ID)
+		if ev.Cards != nil {
+			return
+		}
+		// The list stays empty until the cards are loaded.
+		ev.Cards = []*models.Card{}
+		runQuery(ev, func(ctx context.Context) ([]*models.Card, error) {
+			return ev.service.GetCardsByDeck(ctx, ev.deck.ID)
+		}, func(cards []*models.Card, err error) {
 			if err != nil {
-				core.ErrorDialog(ev, err, "Error Getting Deck")
-				ev.Cards = []*models.Card{}
+				errorSnackbar(ev, err, "Error Getting Deck")
 				return
 			}
 			ev.Cards = cards
-		}
+			ev.contentFrame.Update()
+		})
 	})
-	tree.AddChild(ev, func(w *core.TextField) {
-		w.SetType(core.TextFieldOutlined)
+	tree.AddChild(ev, func(w *core.Frame) {
 		w.Styler(func(s *styles.Style) {
 			s.Grow.Set(1, 0)
-			s.Max.Zero()
-			s.Justify.Items = styles.Center
+			s.Align.Items = styles.Center
 		})
-		w.SetLeadingIcon(icons.Search)
-		w.SetPlaceholder("Search cards...")
-		w.OnInput(func(e events.Event) {
-			ev.searchQuery = w.Text()
-			ev.contentFrame.Update()
+		tree.AddChild(w, func(w *core.TextField) {
+			w.SetType(core.TextFieldOutlined)
+			w.Styler(func(s *styles.Style) {
+				s.Grow.Set(1, 0)
+				s.Max.Zero()
+				s.Justify.Items = styles.Center
+			})
+			w.SetLeadingIcon(icons.Search)
+			w.SetPlaceholder("Search cards... (is:suspended, is:leech, flag:red, tag:name)")
+			w.OnInput(func(e events.Event) {
+				ev.searchQuery = w.Text()
+				ev.contentFrame.Update()
+			})
+		})
+		tree.AddChild(w, func(w *core.Chooser) {
+			names := make([]string, len(cardViews))
+			for i, view := range cardViews {
+				names[i] = view.name
+			}
+			w.SetStrings(names...)
+			w.SetCurrentIndex(0)
+			w.OnChange(func(e events.Event) {
+				ev.view = cardViews[w.CurrentIndex].query
+				ev.contentFrame.Update()
+			})
 		})
-
 	})
 	tree.AddChild(ev, func(w *core.Frame) {
 		ev.contentFrame = w
@@ -71,13 +102,26 @@ func (ev *ExploreView) Init() {
 	})
 }
 
+// cardViews are the filtered views offered next to the search field.
+var cardViews = []struct {
+	name, query string
+}{
+	{"All cards", ""},
+	{"Leeches", "is:leech"},
+	{"Suspended", "is:suspended"},
+	{"Buried", "is:buried"},
+	{"Flagged", "is:flagged"},
+}
+
 func (ev *ExploreView) makeContent(p *tree.Plan) {
-	searchResults := ev.SearchCards(ev.searchQuery)
+	searchResults := ev.SearchCards(strings.TrimSpace(ev.view + " " + ev.searchQuery))
 	if len(searchResults) == 0 {
 		tree.AddAt(p, "empty-state", func(w *emptyState) {
 			w.Updater(func() {
-				if ev.searchQuery == "" {
+				if ev.searchQuery == "" && ev.view == "" {
 					w.SetMessage("No cards in deck")
+				} else if ev.searchQuery == "" {
+					w.SetMessage("No cards in this view")
 				} else {
 					w.SetMessage(fmt.Sprintf("'%s' not found", ev.searchQuery))
 				}
@@ -88,59 +132,217 @@ func (ev *ExploreView) makeContent(p *tree.Plan) {
 		for _, card := range searchResults {
 			tree.AddAt(p, strconv.Itoa(card.ID), func(w *Card) {
 				w.Updater(func() {
-					w.SetData(card)
+					w.SetData(card, ev.service.Calendar.Now())
 				})
 				w.SetEdit(func() {
-					ShowCardDialog(ev, &CardData{
-						Front: card.Front,
-						Back:  card.Back,
-					}, true, func(cd *CardData) {
-						err := ev.service.EditCard(card.ID, cd.Front, cd.Back)
-						if err != nil {
-							core.ErrorDialog(ev, err, "Can't edit card")
+					EditCardDialog(ev, ev.service, card, func(saved, _ []*models.Card) {
+						if card.NoteID == 0 {
+							w.Update()
 							return
 						}
-						card.Front = cd.Front
-						card.Back = cd.Front
-						w.Update()
+						ev.replaceNoteCards(card.NoteID, saved)
 					})
 				})
 				w.SetDelete(func() {
-					if err := ev.service.DeleteCard(card.ID); err != nil {
-						core.ErrorSnackbar(ev, err, "Error While deleting card")
+					siblings := ev.siblings(card)
+					if len(siblings) == 0 {
+						ev.deleteCards(card)
 						return
 					}
-					for index, cardItem := range ev.Cards {
-						if cardItem.ID == card.ID {
-							ev.Cards = append(ev.Cards[:index], ev.Cards[index+1:]...)
-						}
+					DeleteCardDialog(ev, len(siblings), func() {
+						ev.deleteCards(card)
+					}, func() {
+						ev.deleteCards(append(siblings, card)...)
+					})
+				})
+				w.SetSuspend(func() {
+					ctx, cancel := queryContext(ev)
+					defer cancel()
+					if err := ev.service.SuspendCard(ctx, card.ID, !card.Suspended); err != nil {
+						errorSnackbar(ev, err, "Error Suspending Card")
+						return
+					}
+					card.Suspended = !card.Suspended
+					ev.cardStateChanged(w)
+				})
+				w.SetBury(func() {
+					buried := !card.IsBuried(ev.service.Calendar.Now())
+					ctx, cancel := queryContext(ev)
+					defer cancel()
+					if err := ev.service.BuryCard(ctx, card.ID, buried); err != nil {
+						errorSnackbar(ev, err, "Error Burying Card")
+						return
+					}
+					card.BuriedUntil = time.Time{}
+					if buried {
+						card.BuriedUntil = ev.service.Calendar.Tomorrow()
 					}
-					ev.deck.TotalCards--
-					if card.IsDue() {
-						ev.deck.DueCards--
+					ev.cardStateChanged(w)
+				})
+				w.SetTypeAnswer(func() {
+					ctx, cancel := queryContext(ev)
+					defer cancel()
+					if err := ev.service.SetTypeAnswer(ctx, card.ID, !card.TypeAnswer); err != nil {
+						errorSnackbar(ev, err, "Error Updating Card")
+						return
 					}
-					ev.deckListFrame.Update()
-					ev.contentFrame.Update()
+					card.TypeAnswer = !card.TypeAnswer
+					w.Update()
+				})
+				w.SetFlag(func(flag values.Flag) {
+					ctx, cancel := queryContext(ev)
+					defer cancel()
+					if err := ev.service.FlagCard(ctx, card.ID, flag); err != nil {
+						errorSnackbar(ev, err, "Error Flagging Card")
+						return
+					}
+					card.Flag = flag
+					w.Update()
 				})
 			})
 		}
 	}
 }
+
+// cardStateChanged refreshes the card widget and the deck's due count after
+// a card was suspended, buried or brought back.
+func (ev *ExploreView) cardStateChanged(w *Card) {
+	w.Update()
+	ctx, cancel := queryContext(ev)
+	defer cancel()
+	due, err := ev.service.CountDueCardsFromDeck(ctx, ev.deck.ID)
+	if err != nil {
+		errorSnackbar(ev, err, "Error Counting Due Cards")
+		return
+	}
+	ev.deck.DueCards = due
+	ev.deckListFrame.Update()
+}
+
+// siblings returns the other cards generated from the same note as card.
+func (ev *ExploreView) siblings(card *models.Card) []*models.Card {
+	if card.NoteID == 0 {
+		return nil
+	}
+	var siblings []*models.Card
+	for _, cardItem := range ev.Cards {
+		if cardItem.NoteID == card.NoteID && cardItem.ID != card.ID {
+			siblings = append(siblings, cardItem)
+		}
+	}
+	return siblings
+}
+
+// deleteCards deletes cards and drops them from the list. When every card of
+// a note is deleted the note goes with them. Cards that were already gone
+// are reported and dropped all the same.
+func (ev *ExploreView) deleteCards(cards ...*models.Card) {
+	ctx, cancel := queryContext(ev)
+	defer cancel()
+	var err error
+	if len(cards) > 1 || (cards[0].NoteID != 0 && len(ev.siblings(cards[0])) == 0) {
+		err = ev.service.DeleteNote(ctx, cards[0].NoteID)
+	} else {
+		err = ev.service.DeleteCard(ctx, cards[0].ID)
+	}
+	if err != nil {
+		errorSnackbar(ev, err, "Error While deleting card")
+		if !errors.Is(err, services.ErrNotFound) {
+			return
+		}
+	}
+	for _, card := range cards {
+		ev.Cards = slices.DeleteFunc(ev.Cards, func(cardItem *models.Card) bool {
+			return cardItem.ID == card.ID
+		})
+		ev.deck.TotalCards--
+		if card.IsDue(ev.service.Calendar.Now(), ev.service.Calendar.Tomorrow()) {
+			ev.deck.DueCards--
+		}
+	}
+	ev.deckListFrame.Update()
+	ev.contentFrame.Update()
+}
+
+// replaceNoteCards shows cards in place of the note's cards after an edit
+// regenerated them.
+func (ev *ExploreView) replaceNoteCards(noteID int, cards []*models.Card) {
+	kept := make([]*models.Card, 0, len(ev.Cards))
+	removed := 0
+	for _, cardItem := range ev.Cards {
+		if cardItem.NoteID == noteID {
+			removed++
+			continue
+		}
+		kept = append(kept, cardItem)
+	}
+	ev.Cards = append(kept, cards...)
+	ev.deck.TotalCards += len(cards) - removed
+	ev.deckListFrame.Update()
+	ev.contentFrame.Update()
+}
+
 func (ev *ExploreView) SearchCards(query string) []*models.Card {
 
 	if query == "" {
 		return ev.Cards
 	}
+	text, filters := parseSearch(query, ev.service.Calendar.Now(), ev.service.Calendar.Tomorrow())
 	var cards []*models.Card
-	queryLower := strings.ToLower(query)
+	queryLower := strings.ToLower(text)
 	for _, card := range ev.Cards {
 		if card == nil {
 			continue
 		}
-		if strings.Contains(strings.ToLower(card.Front), queryLower) ||
-			strings.Contains(strings.ToLower(card.Back), queryLower) {
+		if !slices.ContainsFunc(filters, func(match func(*models.Card) bool) bool { return !match(card) }) &&
+			(strings.Contains(strings.ToLower(card.Front), queryLower) ||
+				strings.Contains(strings.ToLower(card.Back), queryLower)) {
 			cards = append(cards, card)
 		}
 	}
 	return cards
 }
+
+// parseSearch splits query into free text and the card filters given by
+// is:suspended, is:buried, is:due, is:new, is:flagged, is:leech,
+// flag:<color> and tag:<name> terms. Unknown terms are searched as text.
+// Cards are buried and due as seen at now, and due when scheduled before
+// dayEnd.
+func parseSearch(query string, now, dayEnd time.Time) (string, []func(*models.Card) bool) {
+	var words []string
+	var filters []func(*models.Card) bool
+	for _, word := range strings.Fields(query) {
+		name, value, found := strings.Cut(strings.ToLower(word), ":")
+		if !found {
+			words = append(words, word)
+			continue
+		}
+		switch {
+		case name == "is" && value == "suspended":
+			filters = append(filters, func(card *models.Card) bool { return card.Suspended })
+		case name == "is" && value == "buried":
+			filters = append(filters, func(card *models.Card) bool { return card.IsBuried(now) })
+		case name == "is" && value == "due":
+			filters = append(filters, func(card *models.Card) bool { return card.IsDue(now, dayEnd) })
+		case name == "is" && value == "new":
+			filters = append(filters, (*models.Card).IsNew)
+		case name == "is" && value == "leech":
+			filters = append(filters, (*models.Card).IsLeech)
+		case name == "tag":
+			filters = append(filters, func(card *models.Card) bool { return card.HasTag(value) })
+		case name == "is" && value == "flagged":
+			filters = append(filters, func(card *models.Card) bool { return card.Flag != values.NoFlag })
+		case name == "flag":
+			flag := slices.IndexFunc(values.FlagNames, func(flagName string) bool {
+				return strings.EqualFold(flagName, value)
+			})
+			if number, err := strconv.Atoi(value); err == nil {
+				flag = number
+			}
+			filters = append(filters, func(card *models.Card) bool { return int(card.Flag) == flag })
+		default:
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " "), filters
+}
@@ -2,13 +2,20 @@ package ui
 
 import (
 	"fmt"
+	"html"
 	"image/color"
 	"memoflash/internal/models"
 	"memoflash/internal/values"
+	"memoflash/pkg/typeanswer"
+	"slices"
+	"strings"
+	"time"
 
 	"cogentcore.org/core/colors"
 	"cogentcore.org/core/core"
 	"cogentcore.org/core/events"
+	"cogentcore.org/core/events/key"
+	"cogentcore.org/core/icons"
 	"cogentcore.org/core/styles"
 	"cogentcore.org/core/styles/abilities"
 	"cogentcore.org/core/styles/states"
@@ -24,8 +31,37 @@ type StudyPage struct {
 	CurrentCardIndex int
 	ShowFront        bool
 	showButtons      bool
-	OnEach           func(card *models.Card, rating values.Difficulty) error
+	OnEach           func(card *models.Card, rating values.Difficulty, duration time.Duration) error
 	OnDone           func()
+	OnSuspend        func(card *models.Card) error
+	OnBury           func(card *models.Card) error
+	OnFlag           func(card *models.Card, flag values.Flag) error
+	// OnUndo takes back the rating given to card, which was in the state
+	// before when it was rated. Undo is only offered when it is set.
+	OnUndo func(card *models.Card, before *models.Card, rating values.Difficulty) error
+	// OnEdit opens an editor for card and calls onSaved with the cards the
+	// edit rewrote, and those of them it added.
+	OnEdit func(card *models.Card, onSaved func(saved, added []*models.Card))
+
+	// typed holds the answer typed for a TypeAnswer card, once submitted.
+	typed    string
+	hasTyped bool
+	// shownAt is when the current card was shown; timerText shows the time
+	// since then in whole seconds.
+	shownAt      time.Time
+	timerText    *core.Text
+	timerSeconds int
+	answerField  *core.TextField
+	// history holds the ratings that can be undone, latest last.
+	history []studyAnswer
+}
+
+// studyAnswer is a rating given during a session, with the card's state
+// before it.
+type studyAnswer struct {
+	index  int
+	before models.Card
+	rating values.Difficulty
 }
 
 func (sd *StudyPage) Init() {
@@ -33,19 +69,318 @@ func (sd *StudyPage) Init() {
 	sd.ShowFront = true
 	sd.showButtons = false
 	sd.CurrentCardIndex = 0
+	sd.Styler(func(s *styles.Style) {
+		s.SetAbilities(true, abilities.Focusable)
+	})
+	sd.OnShow(func(e events.Event) {
+		sd.SetFocus()
+		if sd.shownAt.IsZero() {
+			sd.shownAt = time.Now()
+			sd.Animate(sd.tick)
+		}
+	})
+	sd.OnFinal(events.KeyChord, func(e events.Event) {
+		sd.handleKeyChord(e)
+	})
 	sd.makeStudyPage()
 }
 
+// handleKeyChord runs the card action bound to the pressed keys in
+// Settings.Keys. Flags stay on Ctrl+1-4.
+func (sd *StudyPage) handleKeyChord(e events.Event) {
+	chord := e.KeyChord()
+	keys := Settings.Keys
+	switch {
+	case keyBound(keys.Reveal, chord):
+		sd.reveal()
+	case keyBound(keys.Again, chord):
+		sd.rateByKey(values.Again)
+	case keyBound(keys.Hard, chord):
+		sd.rateByKey(values.Hard)
+	case keyBound(keys.Good, chord):
+		sd.rateByKey(values.Good)
+	case keyBound(keys.Easy, chord):
+		sd.rateByKey(values.Easy)
+	case keyBound(keys.Edit, chord):
+		sd.editCard()
+	case keyBound(keys.Suspend, chord):
+		sd.suspendCard()
+	case keyBound(keys.Bury, chord):
+		sd.buryCard()
+	case keyBound(keys.Undo, chord):
+		sd.undo()
+	case keyBound(keys.Exit, chord):
+		if sd.OnDone != nil {
+			sd.OnDone()
+		}
+	case chord == "Control+1":
+		sd.toggleFlag(values.RedFlag)
+	case chord == "Control+2":
+		sd.toggleFlag(values.OrangeFlag)
+	case chord == "Control+3":
+		sd.toggleFlag(values.GreenFlag)
+	case chord == "Control+4":
+		sd.toggleFlag(values.BlueFlag)
+	default:
+		return
+	}
+	e.SetHandled()
+}
+
+// editCard edits the current card in place. The session keeps its position
+// and the cards' scheduling is left alone; only their content is refreshed.
+func (sd *StudyPage) editCard() {
+	card := sd.currentCard()
+	if card == nil || sd.OnEdit == nil {
+		return
+	}
+	sd.OnEdit(card, func(saved, added []*models.Card) {
+		sd.applyEdit(card.NoteID, saved, added)
+		if sd.CurrentCardIndex >= len(sd.Cards) {
+			if sd.OnDone != nil {
+				sd.OnDone()
+			}
+			return
+		}
+		sd.Update()
+		sd.SetFocus()
+	})
+}
+
+// applyEdit brings the session in line with the cards an edit saved. For a
+// note edit, saved holds every card of the note: siblings the edit removed
+// leave the session and the ones it added join at the end.
+func (sd *StudyPage) applyEdit(noteID int, saved, added []*models.Card) {
+	byID := make(map[int]*models.Card, len(saved))
+	for _, savedCard := range saved {
+		byID[savedCard.ID] = savedCard
+	}
+	current := sd.currentCard()
+	kept := sd.Cards[:0]
+	inSession := make(map[int]bool, len(sd.Cards))
+	for i, sessionCard := range sd.Cards {
+		savedCard, ok := byID[sessionCard.ID]
+		if !ok && noteID != 0 && sessionCard.NoteID == noteID {
+			if i < sd.CurrentCardIndex {
+				sd.CurrentCardIndex--
+			}
+			continue
+		}
+		if ok && sessionCard != savedCard {
+			sessionCard.Front = savedCard.Front
+			sessionCard.Back = savedCard.Back
+		}
+		inSession[sessionCard.ID] = true
+		kept = append(kept, sessionCard)
+	}
+	changed := len(kept) != len(sd.Cards)
+	sd.Cards = kept
+	for _, addedCard := range added {
+		if !inSession[addedCard.ID] {
+			sd.Cards = append(sd.Cards, addedCard)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	// Answers point at positions that have moved.
+	sd.history = nil
+	if sd.currentCard() != current {
+		sd.resetAnswer()
+		sd.nextCardShown()
+		sd.ShowFront = true
+		sd.showButtons = false
+	}
+}
+
+// reveal shows the back of the current card. A card asking for a typed
+// answer gets the answer field focused instead.
+func (sd *StudyPage) reveal() {
+	card := sd.currentCard()
+	if card == nil || !sd.ShowFront {
+		return
+	}
+	if card.TypeAnswer && sd.answerField != nil {
+		sd.answerField.SetFocus()
+		return
+	}
+	sd.ShowFront = false
+	sd.showButtons = true
+	sd.Update()
+}
+
+// rateByKey rates the current card once its back is shown.
+func (sd *StudyPage) rateByKey(rating values.Difficulty) {
+	if sd.showButtons {
+		sd.handleRating(rating)
+	}
+}
+
+// undo takes back the latest rating and shows its card again.
+func (sd *StudyPage) undo() {
+	if sd.OnUndo == nil || len(sd.history) == 0 {
+		return
+	}
+	answer := sd.history[len(sd.history)-1]
+	card := sd.Cards[answer.index]
+	if err := sd.OnUndo(card, &answer.before, answer.rating); err != nil {
+		errorSnackbar(sd, err, "Error Undoing Answer")
+		return
+	}
+	sd.history = sd.history[:len(sd.history)-1]
+	*card = answer.before
+	sd.CurrentCardIndex = answer.index
+	sd.resetAnswer()
+	sd.nextCardShown()
+	sd.ShowFront = true
+	sd.showButtons = false
+	core.MessageSnackbar(sd, fmt.Sprintf("Undid %s answer", answer.rating))
+	sd.Update()
+}
+
+func (sd *StudyPage) currentCard() *models.Card {
+	if sd.CurrentCardIndex < len(sd.Cards) {
+		return sd.Cards[sd.CurrentCardIndex]
+	}
+	return nil
+}
+
+// suspendCard suspends the current card and moves on without rating it.
+func (sd *StudyPage) suspendCard() {
+	card := sd.currentCard()
+	if card == nil || sd.OnSuspend == nil {
+		return
+	}
+	if err := sd.OnSuspend(card); err != nil {
+		errorSnackbar(sd, err, "Error Suspending Card")
+		return
+	}
+	core.MessageSnackbar(sd, "Card suspended")
+	sd.skipCard()
+}
+
+// buryCard buries the current card until tomorrow and moves on without
+// rating it.
+func (sd *StudyPage) buryCard() {
+	card := sd.currentCard()
+	if card == nil || sd.OnBury == nil {
+		return
+	}
+	if err := sd.OnBury(card); err != nil {
+		errorSnackbar(sd, err, "Error Burying Card")
+		return
+	}
+	core.MessageSnackbar(sd, "Card buried until tomorrow")
+	sd.skipCard()
+}
+
+// toggleFlag sets flag on the current card, or clears it when the card
+// already carries it.
+func (sd *StudyPage) toggleFlag(flag values.Flag) {
+	card := sd.currentCard()
+	if card == nil || sd.OnFlag == nil {
+		return
+	}
+	if card.Flag == flag {
+		flag = values.NoFlag
+	}
+	if err := sd.OnFlag(card, flag); err != nil {
+		errorSnackbar(sd, err, "Error Flagging Card")
+		return
+	}
+	card.Flag = flag
+	sd.Update()
+}
+
+// tick refreshes the answer timer and reveals the back once the auto-reveal
+// delay has passed.
+func (sd *StudyPage) tick(a *core.Animation) {
+	if sd.currentCard() == nil {
+		a.Done = true
+		return
+	}
+	elapsed := Settings.answerDuration(sd.shownAt)
+	if seconds := int(elapsed.Seconds()); seconds != sd.timerSeconds && sd.timerText != nil {
+		sd.timerSeconds = seconds
+		sd.timerText.Update()
+	}
+	if Settings.AutoRevealSeconds > 0 && sd.ShowFront && !sd.showButtons &&
+		time.Since(sd.shownAt) >= time.Duration(Settings.AutoRevealSeconds)*time.Second {
+		sd.ShowFront = false
+		sd.showButtons = true
+		sd.Update()
+	}
+}
+
+// nextCardShown restarts the answer timer for a newly shown card.
+func (sd *StudyPage) nextCardShown() {
+	sd.shownAt = time.Now()
+	sd.timerSeconds = 0
+}
+
+// submitAnswer records the typed answer and reveals the back.
+func (sd *StudyPage) submitAnswer(answer string) {
+	if !sd.ShowFront {
+		return
+	}
+	sd.typed = answer
+	sd.hasTyped = true
+	sd.ShowFront = false
+	sd.showButtons = true
+	sd.Update()
+	sd.SetFocus()
+}
+
+// suggestedRating returns the rating suggested by the typed answer, if the
+// current card asked for one.
+func (sd *StudyPage) suggestedRating() (values.Difficulty, bool) {
+	card := sd.currentCard()
+	if card == nil || !card.TypeAnswer || !sd.hasTyped {
+		return 0, false
+	}
+	return typeanswer.SuggestRating(typeanswer.PlainText(card.Back), sd.typed), true
+}
+
+// resetAnswer clears the typed answer before the next card is shown.
+func (sd *StudyPage) resetAnswer() {
+	sd.typed = ""
+	sd.hasTyped = false
+}
+
+// skipCard drops the current card from the session.
+func (sd *StudyPage) skipCard() {
+	sd.Cards = append(sd.Cards[:sd.CurrentCardIndex], sd.Cards[sd.CurrentCardIndex+1:]...)
+	// Answers before the skipped card point at shifted positions now.
+	sd.history = nil
+	sd.resetAnswer()
+	sd.nextCardShown()
+	if sd.CurrentCardIndex < len(sd.Cards) {
+		sd.ShowFront = true
+		sd.showButtons = false
+		sd.Update()
+	} else if sd.OnDone != nil {
+		sd.OnDone()
+	}
+}
+
 func (sd *StudyPage) handleRating(rating values.Difficulty) {
 	if sd.OnEach != nil && len(sd.Cards) > sd.CurrentCardIndex {
-		err := sd.OnEach(sd.Cards[sd.CurrentCardIndex], rating)
+		card := sd.Cards[sd.CurrentCardIndex]
+		before := *card
+		before.Tags = slices.Clone(card.Tags)
+		err := sd.OnEach(card, rating, Settings.answerDuration(sd.shownAt))
 		if err != nil {
-			core.ErrorSnackbar(sd, err, "Error Updating Interval")
+			errorSnackbar(sd, err, "Error Updating Interval")
 			return
 		}
+		sd.history = append(sd.history, studyAnswer{index: sd.CurrentCardIndex, before: before, rating: rating})
 	}
 
 	sd.CurrentCardIndex++
+	sd.resetAnswer()
+	sd.nextCardShown()
+	sd.SetFocus()
 	if sd.CurrentCardIndex < len(sd.Cards) {
 		sd.ShowFront = true
 		sd.showButtons = false
@@ -96,6 +431,105 @@ func (sd *StudyPage) makeStudyPage() {
 					meter.SetValue(float32(sd.CurrentCardIndex + 1))
 				})
 			})
+
+			tree.AddChild(progressFrame, func(timerText *core.Text) {
+				sd.timerText = timerText
+				timerText.SetTooltip("Time on this card")
+				timerText.Styler(func(s *styles.Style) {
+					s.SetTextWrap(false)
+					s.Min.X.Em(3)
+					if !Settings.ShowAnswerTimer {
+						s.Display = styles.DisplayNone
+					}
+					if limit := Settings.AnswerTimeLimit; limit > 0 && sd.timerSeconds >= limit {
+						s.Color = colors.Scheme.Error.Base
+					}
+				})
+				timerText.Updater(func() {
+					timerText.SetText(fmt.Sprintf("%d:%02d", sd.timerSeconds/60, sd.timerSeconds%60))
+				})
+			})
+
+			tree.AddChild(progressFrame, func(flagBtn *core.Button) {
+				flagBtn.SetType(core.ButtonAction)
+				flagBtn.SetTooltip("Flag card [Ctrl+1-4]")
+				flagBtn.Styler(func(s *styles.Style) {
+					if card := sd.currentCard(); card != nil && flagColor(card.Flag) != nil {
+						s.Color = colors.Uniform(flagColor(card.Flag))
+					}
+				})
+				flagBtn.Updater(func() {
+					flagBtn.SetIcon(icons.Flag)
+					if card := sd.currentCard(); card != nil && card.Flag != values.NoFlag {
+						flagBtn.SetIcon(icons.FlagFill)
+					}
+				})
+				flagBtn.SetMenu(func(m *core.Scene) {
+					for i, name := range values.FlagNames[1:] {
+						flag := values.Flag(i + 1)
+						btn := core.NewButton(m).SetText(fmt.Sprintf("%s flag [Ctrl+%d]", name, flag)).SetIcon(icons.FlagFill)
+						btn.Styler(func(s *styles.Style) {
+							s.Color = colors.Uniform(FlagColors[flag])
+						})
+						btn.OnClick(func(e events.Event) {
+							sd.toggleFlag(flag)
+						})
+					}
+				})
+			})
+			tree.AddChild(progressFrame, func(editBtn *core.Button) {
+				editBtn.SetType(core.ButtonAction)
+				editBtn.SetIcon(icons.Edit)
+				editBtn.SetTooltip(keyTooltip("Edit card", Settings.Keys.Edit))
+				editBtn.Styler(func(s *styles.Style) {
+					if sd.OnEdit == nil {
+						s.Display = styles.DisplayNone
+					}
+				})
+				editBtn.OnClick(func(e events.Event) {
+					sd.editCard()
+				})
+			})
+			tree.AddChild(progressFrame, func(keysBtn *core.Button) {
+				keysBtn.SetType(core.ButtonAction)
+				keysBtn.SetIcon(icons.Keyboard)
+				keysBtn.SetTooltip("Keyboard shortcuts")
+				keysBtn.OnClick(func(e events.Event) {
+					ShowKeyBindingsDialog(sd)
+				})
+			})
+			tree.AddChild(progressFrame, func(undoBtn *core.Button) {
+				undoBtn.SetType(core.ButtonAction)
+				undoBtn.SetIcon(icons.Undo)
+				undoBtn.SetTooltip(keyTooltip("Undo the last answer", Settings.Keys.Undo))
+				undoBtn.Styler(func(s *styles.Style) {
+					if sd.OnUndo == nil {
+						s.Display = styles.DisplayNone
+					}
+				})
+				undoBtn.Updater(func() {
+					undoBtn.SetState(len(sd.history) == 0, states.Disabled)
+				})
+				undoBtn.OnClick(func(e events.Event) {
+					sd.undo()
+				})
+			})
+			tree.AddChild(progressFrame, func(buryBtn *core.Button) {
+				buryBtn.SetType(core.ButtonAction)
+				buryBtn.SetIcon(icons.VisibilityOff)
+				buryBtn.SetTooltip(keyTooltip("Bury until tomorrow", Settings.Keys.Bury))
+				buryBtn.OnClick(func(e events.Event) {
+					sd.buryCard()
+				})
+			})
+			tree.AddChild(progressFrame, func(suspendBtn *core.Button) {
+				suspendBtn.SetType(core.ButtonAction)
+				suspendBtn.SetIcon(icons.Pause)
+				suspendBtn.SetTooltip(keyTooltip("Suspend", Settings.Keys.Suspend))
+				suspendBtn.OnClick(func(e events.Event) {
+					sd.suspendCard()
+				})
+			})
 		})
 
 		tree.AddChild(container, func(cardFrame *core.Frame) {
@@ -105,8 +539,9 @@ func (sd *StudyPage) makeStudyPage() {
 				s.Background = colors.Scheme.Surface
 				s.Border.Radius.SetAll(units.Dp(10))
 				s.Grow.Set(1, 1)
-				s.Min.Set(units.Dp(450))
-				s.Max.Set(units.Dp(700))
+				min, max, _ := Settings.cardSize()
+				s.Min.Set(units.Dp(min))
+				s.Max.Set(units.Dp(max))
 				s.Direction = styles.Column
 			})
 			cardFrame.OnClick(func(e events.Event) {
@@ -122,7 +557,6 @@ func (sd *StudyPage) makeStudyPage() {
 				})
 
 				tree.AddChild(mainContent, func(titleText *core.Text) {
-					titleText.SetType(core.TextHeadlineLarge)
 					titleText.Styler(func(s *styles.Style) {
 						s.SetNonSelectable()
 						s.Font.Weight = rich.Bold
@@ -131,6 +565,8 @@ func (sd *StudyPage) makeStudyPage() {
 						cardFrame.Send(events.Click, e)
 					})
 					titleText.Updater(func() {
+						_, _, textType := Settings.cardSize()
+						titleText.SetType(textType)
 						if sd.ShowFront {
 							titleText.SetText(sd.Cards[sd.CurrentCardIndex].Front)
 						} else {
@@ -138,6 +574,39 @@ func (sd *StudyPage) makeStudyPage() {
 						}
 					})
 				})
+
+				tree.AddChild(mainContent, func(answerField *core.TextField) {
+					sd.answerField = answerField
+					answerField.SetPlaceholder("Type the answer and press Enter")
+					answerField.Styler(func(s *styles.Style) {
+						s.Min.X.Dp(300)
+						s.Margin.SetTop(units.Dp(20))
+						if card := sd.currentCard(); card == nil || !card.TypeAnswer || !sd.ShowFront {
+							s.Display = styles.DisplayNone
+						}
+					})
+					answerField.Updater(func() {
+						answerField.SetText(sd.typed)
+					})
+					answerField.OnChange(func(e events.Event) {
+						sd.submitAnswer(answerField.Text())
+					})
+				})
+
+				tree.AddChild(mainContent, func(diffText *core.Text) {
+					diffText.SetType(core.TextTitleLarge)
+					diffText.Styler(func(s *styles.Style) {
+						s.Margin.SetTop(units.Dp(20))
+						if _, ok := sd.suggestedRating(); !ok || sd.ShowFront {
+							s.Display = styles.DisplayNone
+						}
+					})
+					diffText.Updater(func() {
+						if card := sd.currentCard(); card != nil && sd.hasTyped {
+							diffText.SetText(answerDiffHTML(typeanswer.Diff(typeanswer.PlainText(card.Back), sd.typed)))
+						}
+					})
+				})
 			})
 
 			tree.AddChild(cardFrame, func(bottomRow *core.Frame) {
@@ -181,6 +650,7 @@ func (sd *StudyPage) makeStudyPage() {
 
 			tree.AddChild(buttonFrame, func(easyBtn *core.Button) {
 				easyBtn.Styler(func(s *styles.Style) {
+					sd.styleSuggested(s, values.Easy)
 					s.Background = colors.Uniform(color.RGBA{160, 210, 160, 255})
 					s.Color = colors.Uniform(color.RGBA{0, 60, 0, 255})
 					s.Font.Weight = rich.Bold
@@ -191,6 +661,7 @@ func (sd *StudyPage) makeStudyPage() {
 						Color:   colors.Uniform(color.RGBA{0, 60, 0, 255}),
 					}}
 				})
+				easyBtn.SetTooltip(keyTooltip("Easy", Settings.Keys.Easy))
 				easyBtn.SetText("Easy").OnClick(func(e events.Event) {
 					sd.handleRating(values.Easy)
 				})
@@ -198,6 +669,7 @@ func (sd *StudyPage) makeStudyPage() {
 
 			tree.AddChild(buttonFrame, func(goodBtn *core.Button) {
 				goodBtn.Styler(func(s *styles.Style) {
+					sd.styleSuggested(s, values.Good)
 					bg := color.RGBA{100, 180, 170, 255}
 					fg := color.RGBA{0, 70, 60, 255}
 					s.Background = colors.Uniform(bg)
@@ -210,6 +682,7 @@ func (sd *StudyPage) makeStudyPage() {
 						Color:   colors.Uniform(fg),
 					}}
 				})
+				goodBtn.SetTooltip(keyTooltip("Good", Settings.Keys.Good))
 				goodBtn.SetText("Good").OnClick(func(e events.Event) {
 					sd.handleRating(values.Good)
 				})
@@ -217,6 +690,7 @@ func (sd *StudyPage) makeStudyPage() {
 
 			tree.AddChild(buttonFrame, func(hardBtn *core.Button) {
 				hardBtn.Styler(func(s *styles.Style) {
+					sd.styleSuggested(s, values.Hard)
 					s.Background = colors.Uniform(color.RGBA{255, 200, 140, 255})
 					s.Color = colors.Uniform(color.RGBA{100, 40, 0, 255})
 					s.Font.Weight = rich.Bold
@@ -227,6 +701,7 @@ func (sd *StudyPage) makeStudyPage() {
 						Color:   colors.Uniform(color.RGBA{100, 40, 0, 255}),
 					}}
 				})
+				hardBtn.SetTooltip(keyTooltip("Hard", Settings.Keys.Hard))
 				hardBtn.SetText("Hard").OnClick(func(e events.Event) {
 					sd.handleRating(values.Hard)
 				})
@@ -234,6 +709,7 @@ func (sd *StudyPage) makeStudyPage() {
 
 			tree.AddChild(buttonFrame, func(againBtn *core.Button) {
 				againBtn.Styler(func(s *styles.Style) {
+					sd.styleSuggested(s, values.Again)
 					bgColor := color.RGBA{240, 140, 140, 255}
 					textColor := color.RGBA{90, 0, 0, 255}
 					s.Background = colors.Uniform(bgColor)
@@ -246,6 +722,7 @@ func (sd *StudyPage) makeStudyPage() {
 						Color:   colors.Uniform(textColor),
 					}}
 				})
+				againBtn.SetTooltip(keyTooltip("Again", Settings.Keys.Again))
 				againBtn.SetText("Again").OnClick(func(e events.Event) {
 					sd.handleRating(values.Again)
 				})
@@ -253,3 +730,37 @@ func (sd *StudyPage) makeStudyPage() {
 		})
 	})
 }
+
+// styleSuggested outlines the rating button matching the typed answer.
+func (sd *StudyPage) styleSuggested(s *styles.Style, rating values.Difficulty) {
+	if suggested, ok := sd.suggestedRating(); ok && suggested == rating {
+		s.Border.Width.SetAll(units.Dp(3))
+		s.Border.Color.SetAll(colors.Scheme.Primary.Base)
+	}
+}
+
+// answerDiffHTML renders a typed answer diff: matching text in green, typed
+// extras struck through in red and missing text underlined in orange.
+func answerDiffHTML(ops []typeanswer.Op) string {
+	var b strings.Builder
+	for _, op := range ops {
+		text := html.EscapeString(op.Text)
+		switch op.Kind {
+		case typeanswer.Equal:
+			fmt.Fprintf(&b, `<span style="color:#2e7d32">%s</span>`, text)
+		case typeanswer.Extra:
+			fmt.Fprintf(&b, `<del style="color:#c62828">%s</del>`, text)
+		case typeanswer.Missing:
+			fmt.Fprintf(&b, `<u style="color:#ef6c00">%s</u>`, text)
+		}
+	}
+	return b.String()
+}
+
+// keyTooltip appends the keys bound to an action to its tooltip.
+func keyTooltip(tooltip string, binding key.Chord) string {
+	if binding == "" {
+		return tooltip
+	}
+	return fmt.Sprintf("%s [%s]", tooltip, binding.Label())
+}
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"memoflash/internal/services"
+	"memoflash/internal/values"
+
+	"cogentcore.org/core/colors"
+	"cogentcore.org/core/core"
+	"cogentcore.org/core/cursors"
+	"cogentcore.org/core/events"
+	"cogentcore.org/core/styles"
+	"cogentcore.org/core/styles/abilities"
+	"cogentcore.org/core/styles/states"
+	"cogentcore.org/core/styles/units"
+	"cogentcore.org/core/text/rich"
+	"cogentcore.org/core/tree"
+)
+
+// GoalCard shows today's progress towards the daily goal as a ring. Clicking
+// it changes the goal.
+type GoalCard struct {
+	core.Frame
+	Progress services.GoalProgress
+	onEdit   func()
+}
+
+func (gc *GoalCard) Init() {
+	gc.Frame.Init()
+	gc.Styler(func(s *styles.Style) {
+		s.SetAbilities(true, abilities.Clickable, abilities.Hoverable)
+		s.Cursor = cursors.Pointer
+		s.Direction = styles.Column
+		s.CenterAll()
+		s.Background = colors.Scheme.SurfaceContainerLow
+		if s.Is(states.Hovered) {
+			s.Background = colors.Scheme.SurfaceContainer
+		}
+		s.Border.Radius.Set(units.Dp(12))
+		s.Grow.Set(1, 1)
+		s.Padding.SetAll(units.Dp(16))
+	})
+	gc.OnClick(func(e events.Event) {
+		if gc.onEdit != nil {
+			gc.onEdit()
+		}
+	})
+
+	tree.AddChild(gc, func(ring *core.Meter) {
+		ring.SetType(core.MeterCircle)
+		ring.Updater(func() {
+			ring.SetValue(gc.Progress.Fraction())
+			ring.Text = "–"
+			if gc.Progress.Goal != nil {
+				ring.Text = fmt.Sprintf("%d/%d", gc.Progress.Done(), gc.Progress.Goal.Target)
+			}
+		})
+		ring.Styler(func(s *styles.Style) {
+			ring.ValueColor = colors.Uniform(colors.Mediumvioletred)
+			if gc.Progress.Met() {
+				ring.ValueColor = colors.Uniform(colors.Springgreen)
+			}
+		})
+		// Runs after the meter's own sizing to fit the ring in the card.
+		ring.FinalStyler(func(s *styles.Style) {
+			s.Min.Set(units.Dp(72))
+			ring.Width.Dp(6)
+			s.Font.Size.Dp(16)
+			s.Font.Weight = rich.Bold
+		})
+	})
+	tree.AddChild(gc, func(title *core.Text) {
+		title.SetText("Daily Goal")
+		title.SetType(core.TextBodyMedium)
+		title.Styler(func(s *styles.Style) {
+			s.Color = colors.Uniform(colors.White)
+		})
+	})
+	tree.AddChild(gc, func(detail *core.Text) {
+		detail.SetType(core.TextBodySmall)
+		detail.Updater(func() {
+			detail.SetText(gc.detail())
+		})
+		detail.Styler(func(s *styles.Style) {
+			s.Color = colors.Scheme.OnSurfaceVariant
+		})
+	})
+}
+
+// OnEdit sets the function called when the card is clicked.
+func (gc *GoalCard) OnEdit(f func()) {
+	gc.onEdit = f
+}
+
+// detail describes the goal, or invites to set one.
+func (gc *GoalCard) detail() string {
+	switch goal := gc.Progress.Goal; {
+	case goal == nil:
+		return "Click to set a goal"
+	case gc.Progress.Met():
+		return "Goal met today"
+	default:
+		return fmt.Sprintf("%s today", goal.Kind)
+	}
+}
+
+// ShowGoalDialog lets the daily goal be changed; a target of zero removes
+// it. onSaved is called once the goal is stored.
+func ShowGoalDialog(ctx core.Widget, service services.GoalService, onSaved func()) {
+	callCtx, cancel := queryContext(ctx)
+	defer cancel()
+	goal, err := service.GetGoal(callCtx)
+	if err != nil {
+		errorSnackbar(ctx, err, "Error Getting Goal")
+		return
+	}
+	kind, target := values.ReviewGoal, 0
+	if goal != nil {
+		kind, target = goal.Kind, goal.Target
+	}
+
+	d := core.NewBody("Daily goal")
+	core.NewText(d).SetType(core.TextBodyMedium).SetText("Set how much to study each day, or zero for no goal")
+	kindChooser := core.NewChooser(d).SetStrings(values.GoalKindNames...)
+	kindChooser.SetCurrentIndex(int(kind))
+	kindChooser.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 0)
+	})
+	kindChooser.OnChange(func(e events.Event) {
+		kind = values.GoalKind(kindChooser.CurrentIndex)
+	})
+	targetSpinner := core.NewSpinner(d).SetMin(0).SetMax(9999).SetStep(5)
+	targetSpinner.SetValue(float32(target))
+	targetSpinner.OnChange(func(e events.Event) {
+		target = int(targetSpinner.Value)
+	})
+
+	d.AddBottomBar(func(bar *core.Frame) {
+		d.AddCancel(bar)
+		d.AddOK(bar).SetText("Save").OnClick(func(e events.Event) {
+			callCtx, cancel := queryContext(ctx)
+			defer cancel()
+			if err := service.SetGoal(callCtx, kind, target); err != nil {
+				errorSnackbar(ctx, err, "Error Saving Goal")
+				return
+			}
+			if onSaved != nil {
+				onSaved()
+			}
+		})
+	})
+	dialog := d.NewDialog(ctx)
+	dialog.SetDisplayTitle(true)
+	dialog.SetResizable(false)
+	dialog.Run()
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	"cogentcore.org/core/core"
+	"cogentcore.org/core/events"
+)
+
+// queryTimeout bounds every service call made from the UI, so that a stuck
+// query ends in an error instead of hanging the app.
+const queryTimeout = 30 * time.Second
+
+// appContext is canceled when the main window closes.
+var appContext = context.Background()
+
+// sceneContexts holds the context of each open window and dialog. It is only
+// used on the UI goroutine.
+var sceneContexts = map[*core.Scene]context.Context{}
+
+// widgetContext returns a context that is canceled once the window or dialog
+// showing w closes, so that queries started from it stop with it.
+func widgetContext(w core.Widget) context.Context {
+	scene := w.AsWidget().Scene
+	if scene == nil || scene.Body == nil {
+		return appContext
+	}
+	if ctx, found := sceneContexts[scene]; found {
+		return ctx
+	}
+	ctx, cancel := context.WithCancel(appContext)
+	sceneContexts[scene] = ctx
+	scene.Body.OnClose(func(e events.Event) {
+		cancel()
+		delete(sceneContexts, scene)
+	})
+	return ctx
+}
+
+// queryContext returns the context of one service call made from w. It is
+// canceled with w's window or dialog, or once queryTimeout has passed.
+func queryContext(w core.Widget) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(widgetContext(w), queryTimeout)
+}
+
+// runQuery runs query off the UI goroutine with a queryContext of w, so that
+// a long query leaves the app responsive, then calls done with its result
+// while holding w's render lock. done is not called once w's window or
+// dialog has closed.
+func runQuery[T any](w core.Widget, query func(ctx context.Context) (T, error), done func(result T, err error)) {
+	parent := widgetContext(w)
+	ctx, cancel := context.WithTimeout(parent, queryTimeout)
+	go func() {
+		defer cancel()
+		result, err := query(ctx)
+		if parent.Err() != nil {
+			return
+		}
+		wb := w.AsWidget()
+		wb.AsyncLock()
+		defer wb.AsyncUnlock()
+		done(result, err)
+	}()
+}
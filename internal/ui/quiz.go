@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"memoflash/internal/models"
+	"memoflash/internal/services"
+	"memoflash/internal/values"
+	"strconv"
+	"time"
+
+	"cogentcore.org/core/colors"
+	"cogentcore.org/core/core"
+	"cogentcore.org/core/events"
+	"cogentcore.org/core/styles"
+	"cogentcore.org/core/styles/abilities"
+	"cogentcore.org/core/styles/states"
+	"cogentcore.org/core/styles/units"
+	"cogentcore.org/core/text/rich"
+	"cogentcore.org/core/tree"
+)
+
+// QuizPage drills cards as multiple choice questions. Picking the right
+// option rates the card Good and a wrong one rates it Again.
+type QuizPage struct {
+	core.Frame
+	Questions []*services.QuizQuestion
+	Current   int
+	OnEach    func(card *models.Card, rating values.Difficulty, duration time.Duration) error
+	OnDone    func()
+
+	// chosen is the option picked for the current question, or -1.
+	chosen int
+	// shownAt is when the current question was shown.
+	shownAt time.Time
+}
+
+func (qp *QuizPage) Init() {
+	qp.Frame.Init()
+	qp.chosen = -1
+	qp.Styler(func(s *styles.Style) {
+		s.SetAbilities(true, abilities.Focusable)
+		s.Direction = styles.Column
+		s.Grow.Set(1, 1)
+		s.CenterAll()
+	})
+	qp.OnShow(func(e events.Event) {
+		qp.SetFocus()
+		if qp.shownAt.IsZero() {
+			qp.shownAt = time.Now()
+		}
+	})
+	qp.OnFinal(events.KeyChord, func(e events.Event) {
+		switch chord := e.KeyChord(); chord {
+		case "1", "2", "3", "4":
+			option, _ := strconv.Atoi(string(chord))
+			qp.choose(option - 1)
+		case "ReturnEnter", " ":
+			qp.next()
+		default:
+			return
+		}
+		e.SetHandled()
+	})
+	qp.makeQuizPage()
+}
+
+func (qp *QuizPage) question() *services.QuizQuestion {
+	if qp.Current < len(qp.Questions) {
+		return qp.Questions[qp.Current]
+	}
+	return nil
+}
+
+// choose answers the current question with option and rates the card.
+func (qp *QuizPage) choose(option int) {
+	question := qp.question()
+	if question == nil || qp.chosen >= 0 || option < 0 || option >= len(question.Options) {
+		return
+	}
+	rating := values.Good
+	if option != question.Answer {
+		rating = values.Again
+	}
+	if qp.OnEach != nil {
+		if err := qp.OnEach(question.Card, rating, Settings.answerDuration(qp.shownAt)); err != nil {
+			errorSnackbar(qp, err, "Error Updating Interval")
+			return
+		}
+	}
+	qp.chosen = option
+	qp.Update()
+}
+
+// next moves on to the following question once the current one is
+// answered.
+func (qp *QuizPage) next() {
+	if qp.chosen < 0 {
+		return
+	}
+	qp.chosen = -1
+	qp.Current++
+	qp.shownAt = time.Now()
+	qp.SetFocus()
+	if qp.Current < len(qp.Questions) {
+		qp.Update()
+	} else if qp.OnDone != nil {
+		qp.OnDone()
+	}
+}
+
+func (qp *QuizPage) makeQuizPage() {
+	tree.AddChild(qp, func(container *core.Frame) {
+		container.Styler(func(s *styles.Style) {
+			s.Direction = styles.Column
+			s.Border.Radius.SetAll(units.Dp(10))
+			s.Padding.SetAll(units.Dp(40))
+			s.Gap.Set(units.Dp(10))
+			s.Background = colors.Scheme.SurfaceContainer
+		})
+
+		tree.AddChild(container, func(progressFrame *core.Frame) {
+			progressFrame.Styler(func(s *styles.Style) {
+				s.Grow.Set(1, 0)
+				s.Align.Items = styles.Center
+			})
+			tree.AddChild(progressFrame, func(textfr *core.Text) {
+				textfr.Updater(func() {
+					textfr.SetText(fmt.Sprintf("%d/%d", qp.Current+1, len(qp.Questions)))
+				})
+			})
+			tree.AddChild(progressFrame, func(meter *core.Meter) {
+				meter.Styler(func(s *styles.Style) {
+					s.Grow.Set(1, 0)
+					s.Min.X.Zero()
+					s.Max.X.Zero()
+				})
+				meter.Updater(func() {
+					meter.SetMax(float32(len(qp.Questions)))
+					meter.SetValue(float32(qp.Current + 1))
+				})
+			})
+		})
+
+		tree.AddChild(container, func(cardFrame *core.Frame) {
+			cardFrame.Styler(func(s *styles.Style) {
+				s.Background = colors.Scheme.Surface
+				s.Border.Radius.SetAll(units.Dp(10))
+				s.Min.Set(units.Dp(450), units.Dp(200))
+				s.Max.X.Dp(700)
+				s.Padding.SetAll(units.Dp(20))
+				s.CenterAll()
+			})
+			tree.AddChild(cardFrame, func(titleText *core.Text) {
+				titleText.SetType(core.TextHeadlineLarge)
+				titleText.Styler(func(s *styles.Style) {
+					s.SetNonSelectable()
+					s.Font.Weight = rich.Bold
+				})
+				titleText.Updater(func() {
+					if question := qp.question(); question != nil {
+						titleText.SetText(question.Card.Front)
+					}
+				})
+			})
+		})
+
+		tree.AddChild(container, func(options *core.Frame) {
+			options.Styler(func(s *styles.Style) {
+				s.Direction = styles.Column
+				s.Gap.Set(units.Dp(8))
+				s.Grow.Set(1, 0)
+			})
+			options.Maker(func(p *tree.Plan) {
+				question := qp.question()
+				if question == nil {
+					return
+				}
+				for i, option := range question.Options {
+					tree.AddAt(p, fmt.Sprintf("%d-%d", qp.Current, i), func(btn *core.Button) {
+						btn.SetType(core.ButtonTonal)
+						btn.SetText(fmt.Sprintf("%d. %s", i+1, option))
+						btn.Styler(func(s *styles.Style) {
+							s.Grow.Set(1, 0)
+							s.Justify.Content = styles.Start
+							if qp.chosen < 0 {
+								return
+							}
+							switch {
+							case i == question.Answer:
+								s.Background = colors.Uniform(color.RGBA{160, 210, 160, 255})
+								s.Color = colors.Uniform(color.RGBA{0, 60, 0, 255})
+							case i == qp.chosen:
+								s.Background = colors.Uniform(color.RGBA{240, 140, 140, 255})
+								s.Color = colors.Uniform(color.RGBA{90, 0, 0, 255})
+							}
+						})
+						btn.OnClick(func(e events.Event) {
+							qp.choose(i)
+						})
+					})
+				}
+			})
+		})
+
+		tree.AddChild(container, func(nextBtn *core.Button) {
+			nextBtn.SetText("Next")
+			nextBtn.SetTooltip("Next question [Enter]")
+			nextBtn.Styler(func(s *styles.Style) {
+				s.Align.Self = styles.End
+			})
+			nextBtn.Updater(func() {
+				nextBtn.SetState(qp.chosen < 0, states.Invisible)
+			})
+			nextBtn.OnClick(func(e events.Event) {
+				qp.next()
+			})
+		})
+	})
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"context"
+	"memoflash/internal/services"
+	"memoflash/pkg/notify"
+	"time"
+)
+
+// reminderInterval is how often the time of the daily reminder is checked
+// for.
+const reminderInterval = time.Minute
+
+// startReminders shows the daily reminder of due cards in the background
+// until ctx is done, which is when the window closes: no reminder comes
+// while the app is not running. Nothing is started when the desktop cannot
+// show notifications.
+func (app *App) startReminders(ctx context.Context) {
+	notifier, err := notify.New("MemoFlash")
+	if err != nil {
+		return
+	}
+	reminder := services.NewReminder(app.Services.CardService, notifier, app.Services.Calendar, Settings.reminderSettings())
+	Settings.reminder = reminder
+	go reminder.Run(ctx, reminderInterval, func(err error) {
+		app.AsyncLock()
+		defer app.AsyncUnlock()
+		errorSnackbar(app, err, "Error Showing Reminder")
+	})
+}
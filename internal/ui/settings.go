@@ -1,11 +1,17 @@
 package ui
 
 import (
+	"fmt"
+	"memoflash/internal/services"
+	"memoflash/pkg/clock"
 	"slices"
+	"strings"
+	"time"
 
 	"cogentcore.org/core/base/iox/tomlx"
 	"cogentcore.org/core/core"
 	"cogentcore.org/core/events"
+	"cogentcore.org/core/events/key"
 	"cogentcore.org/core/styles"
 	"cogentcore.org/core/styles/units"
 	"cogentcore.org/core/tree"
@@ -25,7 +31,10 @@ func (p *ParameterOption) Init() {
 	})
 
 }
-func (p *ParameterOption) makeChooser(title string, options []string, defaultValue *string, onSelect func(s string)) {
+
+// makeChooser adds a chooser of options showing *value, which onSelect
+// receives once changed.
+func (p *ParameterOption) makeChooser(title string, options []string, value *string, onSelect func(s string)) {
 
 	tree.AddChild(p, func(c *core.Text) {
 		c.Styler(func(st *styles.Style) {
@@ -40,20 +49,23 @@ func (p *ParameterOption) makeChooser(title string, options []string, defaultVal
 		c.Styler(func(s *styles.Style) {
 			s.Font.Size.Dp(15)
 		})
+		c.SetStrings(options...)
 		c.Updater(func() {
-			c.SetCurrentIndex(slices.Index(options, *defaultValue))
-
+			c.SetCurrentIndex(slices.Index(options, *value))
 		})
-		c.SetStrings(options...)
 		c.OnChange(func(e events.Event) {
+			*value = c.CurrentItem.GetText()
 			if onSelect != nil {
-				onSelect(c.CurrentItem.GetText())
+				onSelect(*value)
 			}
 		})
 	})
 
 }
-func (p *ParameterOption) makeSpinner(title string, max, min, step float32) {
+
+// makeSpinner adds a spinner editing *value between min and max, calling
+// onChange after every change.
+func (p *ParameterOption) makeSpinner(title string, value *int, min, max, step float32, onChange func()) {
 
 	tree.AddChild(p, func(c *core.Text) {
 		c.Styler(func(st *styles.Style) {
@@ -71,29 +83,294 @@ func (p *ParameterOption) makeSpinner(title string, max, min, step float32) {
 		c.SetMax(max)
 		c.SetMin(min)
 		c.SetStep(step)
-
+		c.Updater(func() {
+			c.SetValue(float32(*value))
+		})
+		c.OnChange(func(e events.Event) {
+			*value = int(c.Value)
+			if onChange != nil {
+				onChange()
+			}
+		})
 	})
 
 }
 
+// makeSwitch adds a switch turning *value on and off, calling onChange after
+// every change.
+func (p *ParameterOption) makeSwitch(title string, value *bool, onChange func()) {
+	tree.AddChild(p, func(c *core.Text) {
+		c.Styler(func(st *styles.Style) {
+			st.SetTextWrap(false)
+		})
+		c.SetText(title)
+	})
+	tree.AddChild(p, func(w *core.Stretch) {})
+	tree.AddChild(p, func(c *core.Switch) {
+		c.Updater(func() {
+			c.SetChecked(*value)
+		})
+		c.OnChange(func(e events.Event) {
+			*value = c.IsChecked()
+			if onChange != nil {
+				onChange()
+			}
+		})
+	})
+}
+
+// ThemeModes and CardSizes are the choices of AppSettings.ThemeMode and
+// AppSettings.CardSize.
+var (
+	ThemeModes = []string{"Auto", "Light", "Dark"}
+	CardSizes  = []string{"Small", "Medium", "Large"}
+)
+
 type AppSettings struct {
 	core.SettingsBase
 
+	// DailyCardLimit caps Study Due Cards across every deck, on top of the
+	// new and review limits of each deck's preset. Studying one deck only
+	// follows its preset.
 	DailyCardLimit int
 
 	ThemeMode string
 	CardSize  string
+
+	// AnswerTimeLimit caps the time recorded for one answer, in seconds;
+	// zero means no cap.
+	AnswerTimeLimit int
+	// ShowAnswerTimer shows how long the current card has been on screen.
+	ShowAnswerTimer bool
+	// AutoRevealSeconds reveals the back of a card after that many seconds;
+	// zero turns it off.
+	AutoRevealSeconds int
+
+	// DayStartHour is the hour at which a new learning day starts. Cards due
+	// tomorrow, buried cards and streaks all roll over then.
+	DayStartHour int
+	// StreakFreezes is the number of days a streak may miss in total before
+	// it breaks; zero turns streak freezes off.
+	StreakFreezes int
+
+	// Reminders shows a desktop notification once a day when cards are due,
+	// as long as the app is open.
+	Reminders bool
+	// ReminderHour and ReminderMinute are the time of day of the reminder.
+	ReminderHour   int
+	ReminderMinute int
+	// QuietHoursStart and QuietHoursEnd are the hours between which no
+	// reminder is shown; equal hours turn quiet hours off.
+	QuietHoursStart int
+	QuietHoursEnd   int
+	// ReminderMinDue is the number of due cards below which no reminder is
+	// shown.
+	ReminderMinDue int
+
+	// StartMinimized starts the app minimised, with the number of due cards
+	// in its title and in the tray.
+	StartMinimized bool
+
+	// Keys are the key bindings of study sessions.
+	Keys StudyKeys
+
+	// calendar follows DayStartHour once the app runs.
+	calendar *clock.Calendar
+	// reminder follows the reminder settings once it runs.
+	reminder *services.Reminder
+}
+
+// StudyKeys binds the actions of a study session to keys. A binding can
+// hold several chords, one per line.
+type StudyKeys struct {
+	Reveal  key.Chord
+	Again   key.Chord
+	Hard    key.Chord
+	Good    key.Chord
+	Easy    key.Chord
+	Edit    key.Chord
+	Suspend key.Chord
+	Bury    key.Chord
+	Undo    key.Chord
+	Exit    key.Chord
+}
+
+func (k *StudyKeys) Defaults() {
+	*k = StudyKeys{
+		Reveal:  " \nReturnEnter",
+		Again:   "1",
+		Hard:    "2",
+		Good:    "3",
+		Easy:    "4",
+		Edit:    "e",
+		Suspend: "s",
+		Bury:    "b",
+		Undo:    "z",
+		Exit:    "Escape",
+	}
+}
+
+// keyAction is a study action and the binding it is triggered by.
+type keyAction struct {
+	Name    string
+	Binding *key.Chord
+}
+
+// actions lists the bindings of k in the order they are shown.
+func (k *StudyKeys) actions() []keyAction {
+	return []keyAction{
+		{"Reveal the answer", &k.Reveal},
+		{"Again", &k.Again},
+		{"Hard", &k.Hard},
+		{"Good", &k.Good},
+		{"Easy", &k.Easy},
+		{"Edit the card", &k.Edit},
+		{"Suspend the card", &k.Suspend},
+		{"Bury the card", &k.Bury},
+		{"Undo the last answer", &k.Undo},
+		{"End the session", &k.Exit},
+	}
+}
+
+// ShowKeyBindingsDialog lets the study key bindings be changed. Pressing a
+// key while a binding is focused replaces it; the changes are saved with the
+// settings on OK.
+func ShowKeyBindingsDialog(ctx core.Widget) {
+	keys := Settings.Keys
+	d := core.NewBody("Keyboard shortcuts")
+	core.NewText(d).SetType(core.TextBodyMedium).SetText("Click a shortcut, then press the new key")
+	list := core.NewFrame(d)
+	list.Styler(func(s *styles.Style) {
+		s.Direction = styles.Column
+		s.Grow.Set(1, 0)
+	})
+	list.Maker(func(p *tree.Plan) {
+		for _, action := range keys.actions() {
+			tree.AddAt(p, action.Name, func(row *ParameterOption) {
+				tree.AddChild(row, func(w *core.Text) {
+					w.SetText(action.Name)
+				})
+				tree.AddChild(row, func(w *core.Stretch) {})
+				tree.AddChild(row, func(w *core.KeyChordButton) {
+					w.FirstUpdater(func() {
+						w.Chord = *action.Binding
+					})
+					w.OnChange(func(e events.Event) {
+						*action.Binding = w.Chord
+					})
+				})
+			})
+		}
+	})
+	d.AddBottomBar(func(bar *core.Frame) {
+		core.NewButton(bar).SetType(core.ButtonOutlined).SetText("Reset").OnClick(func(e events.Event) {
+			keys.Defaults()
+			list.Update()
+		})
+		d.AddCancel(bar)
+		d.AddOK(bar).OnClick(func(e events.Event) {
+			Settings.Keys = keys
+			if err := Settings.Save(); err != nil {
+				errorSnackbar(ctx, err, "Error Saving Settings")
+			}
+		})
+	})
+	d.RunDialog(ctx)
+}
+
+// keyBound reports whether chord is one of the chords of binding.
+func keyBound(binding key.Chord, chord key.Chord) bool {
+	return slices.Contains(strings.Split(string(binding), "\n"), string(chord))
 }
 
 func (s *AppSettings) Defaults() {
 	s.DailyCardLimit = 50
 	s.ThemeMode = "Dark"
 	s.CardSize = "Large"
+	s.AnswerTimeLimit = 60
+	s.DayStartHour = 4
+	s.ReminderHour = 19
+	s.QuietHoursStart = 22
+	s.QuietHoursEnd = 8
+	s.ReminderMinDue = 1
+	s.Keys.Defaults()
+}
+
+// reminderSettings returns the settings of the daily reminder.
+func (s *AppSettings) reminderSettings() services.ReminderSettings {
+	return services.ReminderSettings{
+		Enabled:    s.Reminders,
+		Hour:       s.ReminderHour,
+		Minute:     s.ReminderMinute,
+		QuietStart: s.QuietHoursStart,
+		QuietEnd:   s.QuietHoursEnd,
+		MinDue:     s.ReminderMinDue,
+	}
+}
+
+// Validate reports the first setting out of its range, naming it after its
+// field.
+func (s *AppSettings) Validate() error {
+	checks := []struct {
+		name     string
+		value    int
+		min, max int
+	}{
+		{"DailyCardLimit", s.DailyCardLimit, 0, 9999},
+		{"AnswerTimeLimit", s.AnswerTimeLimit, 0, 3600},
+		{"AutoRevealSeconds", s.AutoRevealSeconds, 0, 3600},
+		{"DayStartHour", s.DayStartHour, 0, 23},
+		{"StreakFreezes", s.StreakFreezes, 0, 365},
+		{"ReminderHour", s.ReminderHour, 0, 23},
+		{"ReminderMinute", s.ReminderMinute, 0, 59},
+		{"QuietHoursStart", s.QuietHoursStart, 0, 23},
+		{"QuietHoursEnd", s.QuietHoursEnd, 0, 23},
+		{"ReminderMinDue", s.ReminderMinDue, 0, 9999},
+	}
+	for _, check := range checks {
+		if check.value < check.min || check.value > check.max {
+			return fmt.Errorf("%s must be between %d and %d, not %d", check.name, check.min, check.max, check.value)
+		}
+	}
+	if !slices.Contains(ThemeModes, s.ThemeMode) {
+		return fmt.Errorf("unknown ThemeMode %q", s.ThemeMode)
+	}
+	if !slices.Contains(CardSizes, s.CardSize) {
+		return fmt.Errorf("unknown CardSize %q", s.CardSize)
+	}
+	return nil
+}
+
+// cardSize returns the smallest and largest size of the card of study
+// sessions, in dp, and the text type of its sides for CardSize.
+func (s *AppSettings) cardSize() (min, max float32, text core.TextTypes) {
+	switch s.CardSize {
+	case "Small":
+		return 300, 450, core.TextHeadlineSmall
+	case "Medium":
+		return 380, 560, core.TextHeadlineMedium
+	}
+	return 450, 700, core.TextHeadlineLarge
+}
+
+// answerDuration returns the time since shownAt, capped at AnswerTimeLimit.
+func (s *AppSettings) answerDuration(shownAt time.Time) time.Duration {
+	elapsed := time.Since(shownAt)
+	if limit := time.Duration(s.AnswerTimeLimit) * time.Second; limit > 0 && elapsed > limit {
+		return limit
+	}
+	return elapsed
 }
 
 func (s *AppSettings) Apply() {
 	core.AppearanceSettings.Theme = getThemeFromText(s.ThemeMode)
 	core.AppearanceSettings.Apply()
+	if s.calendar != nil {
+		s.calendar.SetRolloverHour(s.DayStartHour)
+	}
+	if s.reminder != nil {
+		s.reminder.SetSettings(s.reminderSettings())
+	}
 }
 func (s *AppSettings) Save() error {
 	return tomlx.Save(s, s.Filename())
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"cogentcore.org/core/colors"
+	"cogentcore.org/core/core"
+	"cogentcore.org/core/events"
+	"cogentcore.org/core/icons"
+	"cogentcore.org/core/styles"
+	"cogentcore.org/core/styles/units"
+	"cogentcore.org/core/text/rich"
+	"cogentcore.org/core/tree"
+)
+
+// SettingsTab edits the app settings. Every change is validated, then
+// applied and saved at once.
+type SettingsTab struct {
+	core.Frame
+	// draft holds the settings being edited; it replaces Settings once it
+	// validates.
+	draft AppSettings
+}
+
+func (st *SettingsTab) Init() {
+	st.Frame.Init()
+	st.draft = *Settings
+	st.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 1)
+		s.Direction = styles.Column
+		s.Margin.SetAll(units.Dp(15))
+		s.Gap.Set(units.Dp(10))
+		s.Max.X.Dp(640)
+	})
+	st.OnShow(func(e events.Event) {
+		st.draft = *Settings
+		st.Update()
+	})
+
+	tree.AddChild(st, func(title *core.Text) {
+		title.SetText("Settings").SetType(core.TextHeadlineLarge).Styler(func(s *styles.Style) {
+			s.Font.Weight = rich.Bold
+		})
+	})
+
+	st.heading("study-heading", "Study")
+	tree.AddChildAt(st, "daily-card-limit", func(w *ParameterOption) {
+		w.makeSpinner("Most cards when studying all decks (0 for no limit)", &st.draft.DailyCardLimit, 0, 9999, 5, st.apply)
+		w.SetTooltip("A ceiling across decks, applied after each deck's own new and review limits. Studying a single deck follows only its options.")
+	})
+	tree.AddChildAt(st, "answer-time-limit", func(w *ParameterOption) {
+		w.makeSpinner("Longest answer time recorded, in seconds (0 for no limit)", &st.draft.AnswerTimeLimit, 0, 3600, 5, st.apply)
+	})
+	tree.AddChildAt(st, "show-answer-timer", func(w *ParameterOption) {
+		w.makeSwitch("Show the answer timer", &st.draft.ShowAnswerTimer, st.apply)
+	})
+	tree.AddChildAt(st, "auto-reveal", func(w *ParameterOption) {
+		w.makeSpinner("Reveal the answer after, in seconds (0 to turn off)", &st.draft.AutoRevealSeconds, 0, 3600, 1, st.apply)
+	})
+	tree.AddChildAt(st, "keys", func(w *ParameterOption) {
+		tree.AddChild(w, func(c *core.Text) {
+			c.SetText("Keyboard shortcuts")
+		})
+		tree.AddChild(w, func(c *core.Stretch) {})
+		tree.AddChild(w, func(c *core.Button) {
+			c.SetType(core.ButtonOutlined).SetText("Edit").SetIcon(icons.Keyboard)
+			c.OnClick(func(e events.Event) {
+				ShowKeyBindingsDialog(st)
+			})
+		})
+	})
+
+	st.heading("appearance-heading", "Appearance")
+	tree.AddChildAt(st, "theme", func(w *ParameterOption) {
+		w.makeChooser("Theme", ThemeModes, &st.draft.ThemeMode, func(string) { st.apply() })
+	})
+	tree.AddChildAt(st, "card-size", func(w *ParameterOption) {
+		w.makeChooser("Card size", CardSizes, &st.draft.CardSize, func(string) { st.apply() })
+	})
+
+	st.heading("days-heading", "Learning days")
+	tree.AddChildAt(st, "day-start", func(w *ParameterOption) {
+		w.makeSpinner("New day starts at hour", &st.draft.DayStartHour, 0, 23, 1, st.apply)
+	})
+	tree.AddChildAt(st, "streak-freezes", func(w *ParameterOption) {
+		w.makeSpinner("Days a streak may miss", &st.draft.StreakFreezes, 0, 365, 1, st.apply)
+	})
+
+	st.heading("reminders-heading", "Reminders")
+	tree.AddChildAt(st, "reminders", func(w *ParameterOption) {
+		w.makeSwitch("Remind me of due cards while MemoFlash is open", &st.draft.Reminders, st.apply)
+	})
+	tree.AddChildAt(st, "reminder-hour", func(w *ParameterOption) {
+		w.makeSpinner("Reminder hour", &st.draft.ReminderHour, 0, 23, 1, st.apply)
+	})
+	tree.AddChildAt(st, "reminder-minute", func(w *ParameterOption) {
+		w.makeSpinner("Reminder minute", &st.draft.ReminderMinute, 0, 59, 5, st.apply)
+	})
+	tree.AddChildAt(st, "quiet-start", func(w *ParameterOption) {
+		w.makeSpinner("Quiet hours start at hour", &st.draft.QuietHoursStart, 0, 23, 1, st.apply)
+	})
+	tree.AddChildAt(st, "quiet-end", func(w *ParameterOption) {
+		w.makeSpinner("Quiet hours end at hour", &st.draft.QuietHoursEnd, 0, 23, 1, st.apply)
+	})
+	tree.AddChildAt(st, "reminder-min-due", func(w *ParameterOption) {
+		w.makeSpinner("Remind only with at least this many cards due", &st.draft.ReminderMinDue, 0, 9999, 1, st.apply)
+	})
+	tree.AddChildAt(st, "start-minimized", func(w *ParameterOption) {
+		w.makeSwitch("Start minimised", &st.draft.StartMinimized, st.apply)
+	})
+}
+
+// heading adds a section title named name.
+func (st *SettingsTab) heading(name, title string) {
+	tree.AddChildAt(st, name, func(w *core.Text) {
+		w.SetText(title).SetType(core.TextTitleSmall)
+		w.Styler(func(s *styles.Style) {
+			s.Margin.SetTop(units.Dp(10))
+			s.Color = colors.Scheme.Primary.Base
+		})
+	})
+}
+
+// apply validates the draft, then applies and saves it. An invalid draft is
+// reported and reset to the current settings.
+func (st *SettingsTab) apply() {
+	// The key bindings are edited and saved by their own dialog, and what
+	// the settings drive is set up by the app.
+	st.draft.Keys = Settings.Keys
+	st.draft.calendar, st.draft.reminder = Settings.calendar, Settings.reminder
+	if err := st.draft.Validate(); err != nil {
+		errorSnackbar(st, err, "Invalid Setting")
+		st.draft = *Settings
+		st.Update()
+		return
+	}
+	*Settings = st.draft
+	Settings.Apply()
+	if err := Settings.Save(); err != nil {
+		errorSnackbar(st, err, "Error Saving Settings")
+	}
+}
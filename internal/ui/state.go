@@ -1,6 +1,11 @@
 package ui
 
 import (
+	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"slices"
+	"time"
+
 	"cogentcore.org/core/colors"
 	"cogentcore.org/core/core"
 	"cogentcore.org/core/styles"
@@ -58,3 +63,94 @@ func StatePage(parent core.Widget) *State {
 	card.Message = "Congratulations!"
 	return card
 }
+
+// SessionSummary collects the answers given during a study session.
+type SessionSummary struct {
+	Mode      values.SessionMode
+	StartedAt time.Time
+	Answers   int
+	Ratings   map[values.Difficulty]int
+	// Reviewed counts the answers to learned cards and Lapsed those of
+	// them that were forgotten. Cards still in learning count as new ones.
+	Reviewed int
+	Lapsed   []*models.Card
+	lapses   int
+	// cards and cardLapses count the answers and lapses of each card.
+	cards      map[int]int
+	cardLapses map[int]int
+}
+
+func NewSessionSummary(mode values.SessionMode) *SessionSummary {
+	return &SessionSummary{
+		Mode:       mode,
+		StartedAt:  time.Now(),
+		Ratings:    make(map[values.Difficulty]int),
+		cards:      make(map[int]int),
+		cardLapses: make(map[int]int),
+	}
+}
+
+// Record counts an answer. It must run before the card is rescheduled so
+// learned cards can be told apart from new and learning ones.
+func (summary *SessionSummary) Record(card *models.Card, rating values.Difficulty) {
+	summary.cards[card.ID]++
+	summary.Answers++
+	summary.Ratings[rating]++
+	if card.IsNew() || card.Step > 0 {
+		return
+	}
+	summary.Reviewed++
+	if rating == values.Again {
+		summary.lapses++
+		summary.cardLapses[card.ID]++
+		if !slices.Contains(summary.Lapsed, card) {
+			summary.Lapsed = append(summary.Lapsed, card)
+		}
+	}
+}
+
+// Unrecord takes back an answer counted by Record, for a card that was in
+// the state before when it was answered.
+func (summary *SessionSummary) Unrecord(before *models.Card, rating values.Difficulty) {
+	if summary.cards[before.ID]--; summary.cards[before.ID] <= 0 {
+		delete(summary.cards, before.ID)
+	}
+	summary.Answers--
+	summary.Ratings[rating]--
+	if before.IsNew() || before.Step > 0 {
+		return
+	}
+	summary.Reviewed--
+	if rating == values.Again {
+		summary.lapses--
+		if summary.cardLapses[before.ID]--; summary.cardLapses[before.ID] <= 0 {
+			delete(summary.cardLapses, before.ID)
+			summary.Lapsed = slices.DeleteFunc(summary.Lapsed, func(card *models.Card) bool {
+				return card.ID == before.ID
+			})
+		}
+	}
+}
+
+// Cards returns the number of distinct cards answered.
+func (summary *SessionSummary) Cards() int {
+	return len(summary.cards)
+}
+
+// Session returns the record of the session, ending now.
+func (summary *SessionSummary) Session(deckId int) *models.StudySession {
+	return &models.StudySession{
+		DeckID:    deckId,
+		Mode:      summary.Mode,
+		StartedAt: summary.StartedAt,
+		EndedAt:   time.Now(),
+		Cards:     summary.Cards(),
+		Answers:   summary.Answers,
+		Again:     summary.Ratings[values.Again],
+		Hard:      summary.Ratings[values.Hard],
+		Good:      summary.Ratings[values.Good],
+		Easy:      summary.Ratings[values.Easy],
+		Reviewed:  summary.Reviewed,
+		Lapses:    summary.lapses,
+	}
+}
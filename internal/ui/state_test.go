@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"testing"
+	"time"
+)
+
+func TestSessionSummaryLearningCards(t *testing.T) {
+	now := time.Now()
+	learning := &models.Card{ID: 1, Interval: now.Add(time.Minute), LastStudied: now, Step: 1}
+	learned := &models.Card{ID: 2, Interval: now, LastStudied: now.AddDate(0, 0, -3)}
+
+	summary := NewSessionSummary(values.StudySession)
+	// The learning card was requeued after Again and failed once more.
+	summary.Record(learning, values.Again)
+	summary.Record(learning, values.Again)
+	summary.Record(learned, values.Again)
+	if summary.Reviewed != 1 || len(summary.Lapsed) != 1 || summary.Lapsed[0] != learned {
+		t.Errorf("reviewed = %d, lapsed = %d cards, want only the learned card", summary.Reviewed, len(summary.Lapsed))
+	}
+	if session := summary.Session(0); session.Lapses != 1 || session.Answers != 3 || session.Cards != 2 {
+		t.Errorf("session lapses, answers, cards = %d, %d, %d, want 1, 3, 2", session.Lapses, session.Answers, session.Cards)
+	}
+
+	summary.Unrecord(learning, values.Again)
+	summary.Unrecord(learned, values.Again)
+	if summary.Reviewed != 0 || len(summary.Lapsed) != 0 || summary.lapses != 0 {
+		t.Errorf("after undo: reviewed = %d, lapsed = %d cards, lapses = %d, want none", summary.Reviewed, len(summary.Lapsed), summary.lapses)
+	}
+}
@@ -17,7 +17,9 @@ type StatCard struct {
 	StateIcon icons.Icon
 	Title     string
 	Value     string
-	Color     color.Color
+	// Detail is an optional line shown under the title.
+	Detail string
+	Color  color.Color
 }
 
 func (sc *StatCard) Init() {
@@ -71,6 +73,15 @@ func (sc *StatCard) Init() {
 		})
 
 	})
+	tree.AddChild(sc, func(detail *core.Text) {
+		detail.SetType(core.TextBodySmall)
+		detail.Updater(func() {
+			detail.SetText(sc.Detail)
+		})
+		detail.Styler(func(s *styles.Style) {
+			s.Color = colors.Scheme.OnSurfaceVariant
+		})
+	})
 }
 func (sc *StatCard) SetIcon(icon icons.Icon) {
 	sc.StateIcon = icon
@@ -83,6 +94,10 @@ func (sc *StatCard) SetValue(value string) {
 	sc.Value = value
 }
 
+func (sc *StatCard) SetDetail(detail string) {
+	sc.Detail = detail
+}
+
 func (sc *StatCard) SetColor(color color.Color) {
 	sc.Color = color
 }
@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"memoflash/internal/models"
+	"memoflash/internal/services"
+	"memoflash/internal/utils"
+	"strconv"
+
+	"cogentcore.org/core/colors"
+	"cogentcore.org/core/core"
+	"cogentcore.org/core/events"
+	"cogentcore.org/core/icons"
+	"cogentcore.org/core/styles"
+	"cogentcore.org/core/styles/units"
+	"cogentcore.org/core/text/rich"
+	"cogentcore.org/core/tree"
+)
+
+// sessionHistoryLimit is the number of past sessions listed.
+const sessionHistoryLimit = 50
+
+// goalHistoryDays is the number of days the goals met are counted over.
+const goalHistoryDays = 30
+
+// StatsTab shows statistics about past study sessions.
+type StatsTab struct {
+	core.Frame
+	deckrepo deckrepo
+	services *services.Service
+	Sessions []*models.StudySession
+	// Studied is the percentage of cards ever studied.
+	Studied int
+	// GoalsMet counts the days that met their goal out of GoalDays days
+	// with a goal, over the last goalHistoryDays days.
+	GoalsMet int
+	GoalDays int
+}
+
+func (st *StatsTab) Init() {
+	st.Frame.Init()
+	st.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 1)
+		s.Direction = styles.Column
+		s.Margin.SetAll(units.Dp(15))
+		s.Gap.Set(units.Dp(10))
+	})
+	st.OnShow(func(e events.Event) {
+		runQuery(st, st.fetchStats, func(stats statsTotals, err error) {
+			if err != nil {
+				errorSnackbar(st, err, "Error Getting Stats")
+				return
+			}
+			st.Sessions = stats.sessions
+			st.Studied, st.GoalsMet, st.GoalDays = stats.studied, stats.goalsMet, stats.goalDays
+			st.Update()
+		})
+	})
+
+	tree.AddChild(st, func(title *core.Text) {
+		title.SetText("Statistics").SetType(core.TextHeadlineLarge).Styler(func(s *styles.Style) {
+			s.Font.Weight = rich.Bold
+		})
+	})
+	tree.AddChild(st, func(section *core.Frame) {
+		section.Styler(func(s *styles.Style) {
+			s.Grow.Set(1, 0)
+			s.Direction = styles.Row
+			s.Gap.Set(units.Dp(16))
+		})
+		tree.AddChildAt(section, "studied-card", func(w *StatCard) {
+			w.SetTitle("Cards Studied")
+			w.Updater(func() {
+				w.SetValue(fmt.Sprintf("%d%%", st.Studied))
+			})
+			w.SetIcon(icons.BarChart)
+			w.SetColor(colors.Mediumvioletred)
+		})
+		tree.AddChildAt(section, "goals-card", func(w *StatCard) {
+			w.SetTitle("Goals Met")
+			w.Updater(func() {
+				w.SetValue(fmt.Sprintf("%d/%d", st.GoalsMet, st.GoalDays))
+				w.SetDetail(fmt.Sprintf("Last %d days", goalHistoryDays))
+			})
+			w.SetIcon(icons.Flag)
+			w.SetColor(colors.Springgreen)
+		})
+	})
+	tree.AddChild(st, func(header *core.Text) {
+		header.SetText("Session History").SetType(core.TextTitleLarge).Styler(func(s *styles.Style) {
+			s.Font.Weight = rich.ExtraBold
+		})
+	})
+	tree.AddChildAt(st, "sessions", func(section *core.Frame) {
+		section.Styler(func(s *styles.Style) {
+			s.Grow.Set(1, 1)
+			s.Direction = styles.Column
+			s.Background = colors.Scheme.SurfaceContainerLow
+			s.Border.Radius = styles.BorderRadiusMedium
+			s.Padding.Set(units.Dp(16))
+			s.Gap.Set(units.Dp(8))
+			s.Overflow.Y = styles.OverflowAuto
+		})
+		section.Maker(func(p *tree.Plan) {
+			if len(st.Sessions) == 0 {
+				EmptyState(p, "No sessions yet", icons.History)
+				return
+			}
+			for _, session := range st.Sessions {
+				tree.AddAt(p, strconv.Itoa(session.ID), func(row *core.Frame) {
+					st.makeSessionRow(row, session)
+				})
+			}
+		})
+	})
+}
+
+// statsTotals holds what fetchStats fetched, until it is shown.
+type statsTotals struct {
+	sessions                    []*models.StudySession
+	studied, goalsMet, goalDays int
+}
+
+// fetchStats fetches the past sessions, the share of cards studied and the
+// goals met. It runs off the UI goroutine, so it leaves st alone.
+func (st *StatsTab) fetchStats(ctx context.Context) (statsTotals, error) {
+	var stats statsTotals
+	sessions, err := st.services.GetSessions(ctx, sessionHistoryLimit)
+	if err != nil {
+		return stats, err
+	}
+	stats.sessions = sessions
+	stats.studied, err = st.services.GetProgress(ctx)
+	if err != nil {
+		return stats, err
+	}
+	history, err := st.services.GetGoalProgress(ctx, goalHistoryDays)
+	if err != nil {
+		return stats, err
+	}
+	for _, day := range history {
+		if day.Goal == nil {
+			continue
+		}
+		stats.goalDays++
+		if day.Met() {
+			stats.goalsMet++
+		}
+	}
+	return stats, nil
+}
+
+func (st *StatsTab) makeSessionRow(row *core.Frame, session *models.StudySession) {
+	row.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 0)
+		s.Align.Items = styles.Center
+		s.Gap.Set(units.Dp(16))
+		s.Padding.SetAll(units.Dp(12))
+		s.Border.Radius.Set(units.Dp(12))
+		s.Background = colors.Scheme.SurfaceContainer
+	})
+	tree.AddChild(row, func(w *core.Frame) {
+		w.Styler(func(s *styles.Style) {
+			s.Direction = styles.Column
+			s.Grow.Set(1, 0)
+		})
+		tree.AddChild(w, func(title *core.Text) {
+			title.Styler(func(s *styles.Style) {
+				s.Font.Weight = rich.Bold
+			})
+			title.Updater(func() {
+				deckTitle := "All decks"
+				if deck := st.deckrepo.GetDeck(session.DeckID); deck != nil {
+					deckTitle = deck.Title
+				}
+				title.SetText(fmt.Sprintf("%s · %s", deckTitle, session.Mode))
+			})
+		})
+		tree.AddChild(w, func(date *core.Text) {
+			date.SetType(core.TextBodySmall)
+			date.Styler(func(s *styles.Style) {
+				s.Color = colors.Scheme.OnSurfaceVariant
+			})
+			date.Updater(func() {
+				date.SetText(fmt.Sprintf("%s, %s", session.StartedAt.Format("Mon Jan 2 15:04"), utils.FormatDuration(session.StartedAt)))
+			})
+		})
+	})
+	tree.AddChild(row, func(w *core.Text) {
+		w.Updater(func() {
+			w.SetText(fmt.Sprintf("%d cards · %s · %s retention",
+				session.Cards, utils.FormatElapsed(session.Duration()), formatRetention(session.Retention())))
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"memoflash/internal/models"
 	"memoflash/internal/services"
@@ -19,11 +20,11 @@ import (
 
 type StudyTab struct {
 	core.Frame
-	deckrepo  deckrepo
-	services  *services.Service
-	Due       int
-	DayStreak int
-	Progress  int
+	deckrepo deckrepo
+	services *services.Service
+	Due      int
+	Activity services.Activity
+	Goal     services.GoalProgress
 }
 
 func (st *StudyTab) Init() {
@@ -39,24 +40,34 @@ func (st *StudyTab) Init() {
 	st.createStatsSection()
 	st.createDecksSection()
 }
-func (st *StudyTab) fetchStats() error {
-	due, err := st.services.CountDueCards()
+
+// studyStats holds what fetchStats fetched, until it is shown.
+type studyStats struct {
+	due      int
+	activity services.Activity
+	goal     services.GoalProgress
+}
+
+// fetchStats fetches the due count, the streaks and today's goal progress.
+// It runs off the UI goroutine, so it leaves st alone.
+func (st *StudyTab) fetchStats(ctx context.Context) (studyStats, error) {
+	var stats studyStats
+	due, err := st.services.CountDueCards(ctx)
 	if err != nil {
-		return err
+		return stats, err
 	}
-	st.Due = due
-	daystreak, err := st.services.GetStreak()
+	stats.due = due
+	activity, err := st.services.GetActivity(ctx, Settings.StreakFreezes)
 	if err != nil {
-		return err
+		return stats, err
 	}
-	st.DayStreak = daystreak
-	progress, err := st.services.GetProgress()
+	stats.activity = *activity
+	progress, err := st.services.GetGoalProgress(ctx, 1)
 	if err != nil {
-		return err
+		return stats, err
 	}
-	st.Progress = progress
-	return nil
-
+	stats.goal = *progress[0]
+	return stats, nil
 }
 func (st *StudyTab) makeStudyHeader() {
 	tree.AddChild(st, func(header *core.Frame) {
@@ -104,32 +115,53 @@ func (st *StudyTab) createStatsSection() {
 		tree.AddChildAt(section, "day-streak-card", func(w *StatCard) {
 			w.SetTitle("Day Streak")
 			w.Updater(func() {
-				w.SetValue(fmt.Sprintf("%d", st.DayStreak))
+				w.SetValue(fmt.Sprintf("%d", st.Activity.CurrentStreak))
+				w.SetDetail(st.streakDetail())
 			})
 			w.SetIcon(icons.BoltFill)
 			w.SetColor(colors.Springgreen)
 		})
-		tree.AddChildAt(section, "progress", func(w *StatCard) {
-			w.SetTitle("Progress")
+		tree.AddChildAt(section, "daily-goal", func(w *GoalCard) {
 			w.Updater(func() {
-				w.SetValue(fmt.Sprintf("%d%%", st.Progress))
-
+				w.Progress = st.Goal
+			})
+			w.OnEdit(func() {
+				ShowGoalDialog(w, st.services, func() {
+					st.refreshStats(section)
+				})
 			})
-			w.SetIcon(icons.BarChart)
-			w.SetIcon(icons.BoltFill)
-			w.SetColor(colors.Mediumvioletred)
 		})
-		section.OnFirst(events.Show, func(e events.Event) {
-			err := st.fetchStats()
-			if err != nil {
-				core.ErrorSnackbar(st, err, "Error fetching Stats")
-				return
-			}
-			section.Update()
+		// Refetched on every show so that today's progress includes the
+		// sessions studied since.
+		section.OnShow(func(e events.Event) {
+			st.refreshStats(section)
 		})
 	})
 
 }
+
+// refreshStats fetches the stats again and shows them in section.
+func (st *StudyTab) refreshStats(section *core.Frame) {
+	runQuery(st, st.fetchStats, func(stats studyStats, err error) {
+		if err != nil {
+			errorSnackbar(st, err, "Error fetching Stats")
+			return
+		}
+		st.Due, st.Activity, st.Goal = stats.due, stats.activity, stats.goal
+		section.Update()
+	})
+}
+
+// streakDetail describes the longest streak, the days studied and the
+// freezes left under the current streak.
+func (st *StudyTab) streakDetail() string {
+	detail := fmt.Sprintf("Best %d · %d days studied", st.Activity.LongestStreak, st.Activity.DaysStudied)
+	if Settings.StreakFreezes > 0 && st.Activity.CurrentStreak > 0 {
+		detail += fmt.Sprintf(" · %d freezes left", st.Activity.FreezesLeft)
+	}
+	return detail
+}
+
 func (st *StudyTab) GetRecentDecks() []*models.Deck {
 	sortedList := make([]*models.Deck, 0)
 	for _, item := range st.deckrepo.GetDecks() {
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"memoflash/internal/models"
+	"memoflash/internal/services"
+	"memoflash/internal/utils"
+	"memoflash/internal/values"
+	"strconv"
+	"time"
+
+	"cogentcore.org/core/colors"
+	"cogentcore.org/core/core"
+	"cogentcore.org/core/events"
+	"cogentcore.org/core/icons"
+	"cogentcore.org/core/styles"
+	"cogentcore.org/core/styles/units"
+	"cogentcore.org/core/text/rich"
+	"cogentcore.org/core/tree"
+)
+
+// SummaryPage shows the statistics of a finished session, when the next
+// card is due and the cards that were forgotten, each with an edit link.
+type SummaryPage struct {
+	core.Frame
+	Title   string
+	Session *models.StudySession
+	Lapsed  []*models.Card
+	service *services.Service
+	nextDue time.Time
+}
+
+func (sp *SummaryPage) Init() {
+	sp.Frame.Init()
+	sp.Styler(func(s *styles.Style) {
+		s.Direction = styles.Column
+		s.Gap.Set(units.Dp(20))
+		s.Border.Radius.SetAll(units.Dp(10))
+		s.Padding.Set(units.Dp(40))
+		s.Background = colors.Scheme.SurfaceContainerLow
+		s.Min.X.Dp(600)
+	})
+	sp.OnShow(func(e events.Event) {
+		ctx, cancel := queryContext(sp)
+		defer cancel()
+		next, err := sp.service.GetNextDue(ctx, sp.Session.DeckID)
+		if err != nil {
+			errorSnackbar(sp, err, "Error Getting Next Due Card")
+			return
+		}
+		sp.nextDue = next
+		sp.Update()
+	})
+
+	tree.AddChild(sp, func(w *core.Text) {
+		w.SetType(core.TextHeadlineSmall)
+		w.Styler(func(s *styles.Style) {
+			s.Font.Weight = rich.Bold
+		})
+		w.Updater(func() {
+			w.SetText(sp.Title)
+		})
+	})
+
+	tree.AddChild(sp, func(section *core.Frame) {
+		section.Styler(func(s *styles.Style) {
+			s.Grow.Set(1, 0)
+			s.Gap.Set(units.Dp(16))
+		})
+		tree.AddChild(section, func(w *StatCard) {
+			w.SetTitle("Cards")
+			w.SetIcon(icons.Style)
+			w.SetColor(colors.Orange)
+			w.Updater(func() {
+				w.SetValue(strconv.Itoa(sp.Session.Cards))
+			})
+		})
+		tree.AddChild(section, func(w *StatCard) {
+			w.SetTitle("Time")
+			w.SetIcon(icons.Timer)
+			w.SetColor(colors.Deepskyblue)
+			w.Updater(func() {
+				w.SetValue(utils.FormatElapsed(sp.Session.Duration()))
+			})
+		})
+		tree.AddChild(section, func(w *StatCard) {
+			w.SetTitle("Per answer")
+			w.SetIcon(icons.Speed)
+			w.SetColor(colors.Mediumvioletred)
+			w.Updater(func() {
+				perAnswer := time.Duration(0)
+				if sp.Session.Answers > 0 {
+					perAnswer = sp.Session.Duration() / time.Duration(sp.Session.Answers)
+				}
+				w.SetValue(fmt.Sprintf("%.1fs", perAnswer.Seconds()))
+			})
+		})
+		tree.AddChild(section, func(w *StatCard) {
+			w.SetTitle("Retention")
+			w.SetIcon(icons.Check)
+			w.SetColor(colors.Springgreen)
+			w.Updater(func() {
+				w.SetValue(formatRetention(sp.Session.Retention()))
+			})
+		})
+	})
+
+	tree.AddChild(sp, func(w *core.Text) {
+		w.Updater(func() {
+			w.SetText(fmt.Sprintf("%s <b>%d</b> · %s <b>%d</b> · %s <b>%d</b> · %s <b>%d</b>",
+				values.Again, sp.Session.Again, values.Hard, sp.Session.Hard,
+				values.Good, sp.Session.Good, values.Easy, sp.Session.Easy))
+		})
+	})
+
+	tree.AddChild(sp, func(w *core.Text) {
+		w.Updater(func() {
+			if sp.nextDue.IsZero() {
+				w.SetText("No cards are scheduled")
+				return
+			}
+			w.SetText(fmt.Sprintf("Next card due %s, %s",
+				utils.FormatUntil(sp.nextDue), sp.nextDue.Format("Mon Jan 2 15:04")))
+		})
+	})
+
+	tree.AddChild(sp, func(lapsed *core.Frame) {
+		lapsed.Styler(func(s *styles.Style) {
+			s.Direction = styles.Column
+			s.Gap.Set(units.Dp(8))
+			s.Grow.Set(1, 0)
+			s.Max.Y.Dp(300)
+			s.Overflow.Y = styles.OverflowAuto
+		})
+		lapsed.Maker(func(p *tree.Plan) {
+			if len(sp.Lapsed) == 0 {
+				return
+			}
+			tree.AddAt(p, "lapsed-title", func(w *core.Text) {
+				w.SetType(core.TextTitleMedium)
+				w.SetText(fmt.Sprintf("Forgotten cards (%d)", len(sp.Lapsed)))
+			})
+			for _, card := range sp.Lapsed {
+				tree.AddAt(p, strconv.Itoa(card.ID), func(row *core.Frame) {
+					row.Styler(func(s *styles.Style) {
+						s.Grow.Set(1, 0)
+						s.Align.Items = styles.Center
+						s.Padding.SetAll(units.Dp(8))
+						s.Border.Radius.SetAll(units.Dp(8))
+						s.Background = colors.Scheme.SurfaceContainer
+					})
+					tree.AddChild(row, func(w *core.Text) {
+						w.Styler(func(s *styles.Style) {
+							s.Grow.Set(1, 0)
+						})
+						w.Updater(func() {
+							w.SetText(card.Front)
+						})
+					})
+					tree.AddChild(row, func(w *core.Button) {
+						w.SetType(core.ButtonAction)
+						w.SetIcon(icons.Edit)
+						w.SetTooltip("Edit card")
+						w.OnClick(func(e events.Event) {
+							EditCardDialog(sp, sp.service, card, func(_, _ []*models.Card) {
+								row.Update()
+							})
+						})
+					})
+				})
+			}
+		})
+	})
+}
+
+// formatRetention formats a StudySession.Retention value.
+func formatRetention(retention float64) string {
+	if retention < 0 {
+		return "–"
+	}
+	return fmt.Sprintf("%.0f%%", retention*100)
+}
+
+// NewSummaryPage adds a SummaryPage for session to parent, centered.
+// lapsed are the cards forgotten during the session.
+func NewSummaryPage(parent core.Widget, service *services.Service, session *models.StudySession, lapsed []*models.Card) *SummaryPage {
+	fr := core.NewFrame(parent)
+	fr.Styler(func(s *styles.Style) {
+		s.Grow.Set(1, 1)
+		s.CenterAll()
+	})
+	page := tree.New[SummaryPage](fr)
+	page.service = service
+	page.Session = session
+	page.Lapsed = lapsed
+	page.Title = session.Mode.String() + " Complete!"
+	return page
+}
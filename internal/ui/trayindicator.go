@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+
+	"cogentcore.org/core/core"
+	"fyne.io/systray"
+)
+
+// trayIndicator is the DueIndicator of the system tray: an icon whose title
+// and tooltip give the number of due cards. Clicking it, or its Open Study
+// item, brings the window back on the Study tab, so the app can be left
+// minimised.
+type trayIndicator struct{}
+
+// newTrayIndicator places the icon in the tray, which calls activate when
+// clicked, until ctx is done. A desktop without a tray only logs that the
+// icon could not be shown.
+func newTrayIndicator(ctx context.Context, app *App, activate func()) *trayIndicator {
+	onUI := func(f func()) {
+		app.AsyncLock()
+		defer app.AsyncUnlock()
+		f()
+	}
+	systray.SetOnTapped(func() { onUI(activate) })
+	start, _ := systray.RunWithExternalLoop(func() {
+		systray.SetIcon(trayIcon())
+		systray.SetTooltip("MemoFlash")
+		open := systray.AddMenuItem("Open Study", "Show the cards due today")
+		systray.AddSeparator()
+		quit := systray.AddMenuItem("Quit MemoFlash", "")
+		// The tray shows the menu only once this function returns.
+		go func() {
+			for {
+				select {
+				case <-open.ClickedCh:
+					onUI(activate)
+				case <-quit.ClickedCh:
+					onUI(core.TheApp.Quit)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}, nil)
+	start()
+	return &trayIndicator{}
+}
+
+func (t *trayIndicator) SetDue(due int) {
+	title, tooltip := "", "MemoFlash: no cards due"
+	if due > 0 {
+		title = fmt.Sprint(due)
+		tooltip = fmt.Sprintf("MemoFlash: %d cards due", due)
+	}
+	systray.SetTitle(title)
+	systray.SetTooltip(tooltip)
+}
+
+// trayIconSize is the width and height of the tray icon in pixels.
+const trayIconSize = 32
+
+// trayIcon draws the tray icon, a card on a round badge, encoded as PNG, or
+// as an ICO holding the PNG on Windows.
+func trayIcon() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, trayIconSize, trayIconSize))
+	badge := color.RGBA{103, 80, 164, 255}
+	card := color.RGBA{255, 255, 255, 255}
+	center := float64(trayIconSize-1) / 2
+	for y := range trayIconSize {
+		for x := range trayIconSize {
+			dx, dy := float64(x)-center, float64(y)-center
+			switch {
+			case x >= 9 && x < 23 && y >= 7 && y < 25:
+				img.Set(x, y, card)
+			case dx*dx+dy*dy <= center*center:
+				img.Set(x, y, badge)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil
+	}
+	if runtime.GOOS != "windows" {
+		return buf.Bytes()
+	}
+	// An ICO header and a single directory entry pointing at the PNG.
+	var ico bytes.Buffer
+	binary.Write(&ico, binary.LittleEndian, []uint16{0, 1, 1})
+	ico.Write([]byte{trayIconSize, trayIconSize, 0, 0})
+	binary.Write(&ico, binary.LittleEndian, []uint16{1, 32})
+	binary.Write(&ico, binary.LittleEndian, []uint32{uint32(buf.Len()), 22})
+	ico.Write(buf.Bytes())
+	return ico.Bytes()
+}
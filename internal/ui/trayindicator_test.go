@@ -0,0 +1,21 @@
+package ui
+
+import (
+	"bytes"
+	"image/png"
+	"runtime"
+	"testing"
+)
+
+func TestTrayIcon(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the icon is wrapped in an ICO on Windows")
+	}
+	img, err := png.Decode(bytes.NewReader(trayIcon()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != trayIconSize || size.Y != trayIconSize {
+		t.Errorf("icon size = %v, want %d×%d", size, trayIconSize, trayIconSize)
+	}
+}
@@ -32,3 +32,69 @@ func FormatDuration(date time.Time) string {
 		return fmt.Sprintf("%.0f years ago", math.Round(since.Hours()/(24*365)))
 	}
 }
+
+// FormatUntil describes how far in the future date is, e.g. "in 3 hours".
+func FormatUntil(date time.Time) string {
+	until := time.Until(date)
+	switch {
+	case 60 > until.Seconds():
+		return "Now"
+	case 60 > until.Minutes():
+		return fmt.Sprintf("in %.0f minutes", until.Round(time.Minute).Minutes())
+	case 24 > until.Hours():
+		return fmt.Sprintf("in %.0f hours", until.Round(time.Hour).Hours())
+	case 24*30 > until.Hours():
+		return fmt.Sprintf("in %.0f days", math.Round(until.Hours()/24))
+	case 24*365 > until.Hours():
+		return fmt.Sprintf("in %.0f months", math.Round(until.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("in %.0f years", math.Round(until.Hours()/(24*365)))
+	}
+}
+
+// FormatElapsed formats a time spent studying, e.g. "45s", "3m 20s" or
+// "1h 05m".
+func FormatElapsed(elapsed time.Duration) string {
+	elapsed = elapsed.Round(time.Second)
+	switch {
+	case elapsed < time.Minute:
+		return fmt.Sprintf("%ds", int(elapsed.Seconds()))
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm %02ds", int(elapsed.Minutes()), int(elapsed.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh %02dm", int(elapsed.Hours()), int(elapsed.Minutes())%60)
+	}
+}
+
+// ParseSteps parses space separated durations such as "1m 10m 1h".
+func ParseSteps(steps string) ([]time.Duration, error) {
+	var durations []time.Duration
+	for _, field := range strings.Fields(steps) {
+		duration, err := time.ParseDuration(field)
+		if err != nil {
+			return nil, err
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("step %q must be positive", field)
+		}
+		durations = append(durations, duration)
+	}
+	return durations, nil
+}
+
+// FormatSteps formats durations the way ParseSteps reads them, dropping zero
+// units so that 10 minutes reads "10m" rather than "10m0s".
+func FormatSteps(durations []time.Duration) string {
+	steps := make([]string, len(durations))
+	for i, duration := range durations {
+		step := duration.String()
+		if strings.HasSuffix(step, "m0s") {
+			step = strings.TrimSuffix(step, "0s")
+		}
+		if strings.HasSuffix(step, "h0m") {
+			step = strings.TrimSuffix(step, "0m")
+		}
+		steps[i] = step
+	}
+	return strings.Join(steps, " ")
+}
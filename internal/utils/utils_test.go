@@ -51,3 +51,72 @@ func TestFormatDuration(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatUntil(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		input    time.Time
+		expected string
+	}{
+		{now.Add(-time.Hour), "Now"},
+		{now.Add(30 * time.Second), "Now"},
+		{now.Add(10*time.Minute + time.Second), "in 10 minutes"},
+		{now.Add(3*time.Hour + time.Second), "in 3 hours"},
+		{now.Add(2*24*time.Hour + time.Second), "in 2 days"},
+		{now.Add(90*24*time.Hour + time.Second), "in 3 months"},
+		{now.Add(2*365*24*time.Hour + time.Second), "in 2 years"},
+	}
+
+	for _, tt := range tests {
+		if got := utils.FormatUntil(tt.input); got != tt.expected {
+			t.Errorf("FormatUntil(%v) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{3*time.Minute + 5*time.Second, "3m 05s"},
+		{time.Hour + 5*time.Minute + 40*time.Second, "1h 05m"},
+	}
+
+	for _, tt := range tests {
+		if got := utils.FormatElapsed(tt.input); got != tt.expected {
+			t.Errorf("FormatElapsed(%v) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSteps(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"1m 10m", "1m 10m"},
+		{"90s 1h 1h30m", "1m30s 1h 1h30m"},
+		{"  2h   30s ", "2h 30s"},
+	}
+
+	for _, tt := range tests {
+		steps, err := utils.ParseSteps(tt.input)
+		if err != nil {
+			t.Errorf("ParseSteps(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := utils.FormatSteps(steps); got != tt.expected {
+			t.Errorf("FormatSteps(ParseSteps(%q)) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+	for _, input := range []string{"10", "1x", "-1m"} {
+		if _, err := utils.ParseSteps(input); err == nil {
+			t.Errorf("ParseSteps(%q) should fail", input)
+		}
+	}
+}
@@ -8,3 +8,95 @@ const (
 	Hard
 	Again
 )
+
+var DifficultyNames = []string{"Easy", "Good", "Hard", "Again"}
+
+func (d Difficulty) String() string {
+	if d < 0 || int(d) >= len(DifficultyNames) {
+		return "Unknown"
+	}
+	return DifficultyNames[d]
+}
+
+// Flag is a colored marker a user can put on a card.
+type Flag int
+
+const (
+	NoFlag Flag = iota
+	RedFlag
+	OrangeFlag
+	GreenFlag
+	BlueFlag
+)
+
+var FlagNames = []string{"None", "Red", "Orange", "Green", "Blue"}
+
+func (f Flag) String() string {
+	if f < 0 || int(f) >= len(FlagNames) {
+		return FlagNames[NoFlag]
+	}
+	return FlagNames[f]
+}
+
+// DefaultLeechThreshold is the number of lapses that makes a card a leech
+// unless the deck says otherwise.
+const DefaultLeechThreshold = 8
+
+// LeechAction is what happens to a card once it becomes a leech.
+type LeechAction int
+
+const (
+	// TagLeech only tags the card so it shows up in the leech view.
+	TagLeech LeechAction = iota
+	// SuspendLeech tags the card and suspends it.
+	SuspendLeech
+)
+
+var LeechActionNames = []string{"Tag only", "Tag and suspend"}
+
+func (a LeechAction) String() string {
+	if a < 0 || int(a) >= len(LeechActionNames) {
+		return LeechActionNames[TagLeech]
+	}
+	return LeechActionNames[a]
+}
+
+// SessionMode is the kind of study session a learner ran.
+type SessionMode int
+
+const (
+	// StudySession reviews cards and reschedules them.
+	StudySession SessionMode = iota
+	// CramSession goes through cards without rescheduling them.
+	CramSession
+	// QuizSession asks the cards as multiple choice questions.
+	QuizSession
+)
+
+var SessionModeNames = []string{"Study", "Cram", "Quiz"}
+
+func (m SessionMode) String() string {
+	if m < 0 || int(m) >= len(SessionModeNames) {
+		return SessionModeNames[StudySession]
+	}
+	return SessionModeNames[m]
+}
+
+// GoalKind is what a daily goal counts.
+type GoalKind int
+
+const (
+	// ReviewGoal counts the answers given in a day.
+	ReviewGoal GoalKind = iota
+	// MinuteGoal counts the minutes spent answering in a day.
+	MinuteGoal
+)
+
+var GoalKindNames = []string{"Reviews", "Minutes"}
+
+func (k GoalKind) String() string {
+	if k < 0 || int(k) >= len(GoalKindNames) {
+		return GoalKindNames[ReviewGoal]
+	}
+	return GoalKindNames[k]
+}
@@ -0,0 +1,48 @@
+package cardtemplate
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FrontSide is the special field available to back templates that holds the
+// rendered front of the same card.
+const FrontSide = "FrontSide"
+
+var fieldPattern = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// Render replaces every {{Field}} in tmpl with the matching value from fields.
+// Unknown fields render as an empty string.
+func Render(tmpl string, fields map[string]string) string {
+	return fieldPattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name := fieldPattern.FindStringSubmatch(match)[1]
+		return fields[name]
+	})
+}
+
+// RenderCard renders both sides of a card. The back template can refer to the
+// rendered front through {{FrontSide}}.
+func RenderCard(front, back string, fields map[string]string) (string, string) {
+	renderedFront := Render(front, fields)
+	backFields := make(map[string]string, len(fields)+1)
+	for name, value := range fields {
+		backFields[name] = value
+	}
+	backFields[FrontSide] = renderedFront
+	return renderedFront, Render(back, backFields)
+}
+
+// Fields lists the field names referenced by tmpl in order of appearance.
+func Fields(tmpl string) []string {
+	var names []string
+	for _, match := range fieldPattern.FindAllStringSubmatch(tmpl, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// IsEmpty reports whether a rendered side has no visible content, in which
+// case no card should be generated for it.
+func IsEmpty(rendered string) bool {
+	return strings.TrimSpace(rendered) == ""
+}
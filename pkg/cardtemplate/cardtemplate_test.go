@@ -0,0 +1,86 @@
+package cardtemplate_test
+
+import (
+	"memoflash/pkg/cardtemplate"
+	"reflect"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	fields := map[string]string{"Front": "Hola", "Back": "Hello", "Extra": ""}
+	tests := []struct {
+		tmpl     string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{"{{Front}}", "Hola"},
+		{"{{ Front }} means {{Back}}", "Hola means Hello"},
+		{"{{Missing}}!", "!"},
+		{"{{Extra}}", ""},
+		{"{Front} {{Front}", "{Front} {{Front}"},
+		{"{{Front}}{{Front}}", "HolaHola"},
+	}
+
+	for _, tt := range tests {
+		if got := cardtemplate.Render(tt.tmpl, fields); got != tt.expected {
+			t.Errorf("Render(%q) = %q, want %q", tt.tmpl, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderCard(t *testing.T) {
+	fields := map[string]string{"Front": "Hola", "Back": "Hello"}
+	tests := []struct {
+		front, back         string
+		wantFront, wantBack string
+	}{
+		{"{{Front}}", "{{Back}}", "Hola", "Hello"},
+		{"{{Front}}", "{{FrontSide}}<hr>{{Back}}", "Hola", "Hola<hr>Hello"},
+		{"{{Back}}", "{{FrontSide}} = {{Front}}", "Hello", "Hello = Hola"},
+		{"{{FrontSide}}", "{{FrontSide}}", "", ""},
+	}
+
+	for _, tt := range tests {
+		front, back := cardtemplate.RenderCard(tt.front, tt.back, fields)
+		if front != tt.wantFront || back != tt.wantBack {
+			t.Errorf("RenderCard(%q, %q) = %q, %q, want %q, %q", tt.front, tt.back, front, back, tt.wantFront, tt.wantBack)
+		}
+	}
+	if _, found := fields[cardtemplate.FrontSide]; found {
+		t.Errorf("RenderCard() added %s to the fields", cardtemplate.FrontSide)
+	}
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		tmpl     string
+		expected []string
+	}{
+		{"no fields", nil},
+		{"{{Front}}", []string{"Front"}},
+		{"{{FrontSide}}<hr>{{ Back }} {{cloze:Text}}", []string{"FrontSide", "Back", "cloze:Text"}},
+	}
+
+	for _, tt := range tests {
+		if got := cardtemplate.Fields(tt.tmpl); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Fields(%q) = %v, want %v", tt.tmpl, got, tt.expected)
+		}
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	tests := []struct {
+		rendered string
+		expected bool
+	}{
+		{"", true},
+		{" \n\t", true},
+		{"Hola", false},
+	}
+
+	for _, tt := range tests {
+		if got := cardtemplate.IsEmpty(tt.rendered); got != tt.expected {
+			t.Errorf("IsEmpty(%q) = %v, want %v", tt.rendered, got, tt.expected)
+		}
+	}
+}
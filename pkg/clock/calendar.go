@@ -0,0 +1,73 @@
+package clock
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Calendar splits time into learning days. A learning day starts at the
+// rollover hour, in local time, rather than at midnight, so that a late
+// night session still counts for the day it started on. It is safe for
+// concurrent use.
+type Calendar struct {
+	clock    Clock
+	rollover atomic.Int32
+}
+
+// NewCalendar returns a Calendar reading the time from clock whose days
+// start at rolloverHour.
+func NewCalendar(clock Clock, rolloverHour int) *Calendar {
+	c := &Calendar{clock: clock}
+	c.SetRolloverHour(rolloverHour)
+	return c
+}
+
+// Now returns the current time of the calendar's clock.
+func (c *Calendar) Now() time.Time {
+	return c.clock.Now()
+}
+
+// RolloverHour returns the hour at which a new learning day starts.
+func (c *Calendar) RolloverHour() int {
+	return int(c.rollover.Load())
+}
+
+// SetRolloverHour sets the hour at which a new learning day starts. Hours
+// out of the range 0 to 23 are clamped to it.
+func (c *Calendar) SetRolloverHour(hour int) {
+	c.rollover.Store(int32(min(max(hour, 0), 23)))
+}
+
+// DayStart returns the start of the learning day t falls on.
+func (c *Calendar) DayStart(t time.Time) time.Time {
+	t = t.In(time.Local)
+	year, month, day := t.Date()
+	start := time.Date(year, month, day, c.RolloverHour(), 0, 0, 0, time.Local)
+	if t.Before(start) {
+		start = time.Date(year, month, day-1, c.RolloverHour(), 0, 0, 0, time.Local)
+	}
+	return start
+}
+
+// NextDayStart returns the start of the learning day after the one t falls
+// on.
+func (c *Calendar) NextDayStart(t time.Time) time.Time {
+	year, month, day := c.DayStart(t).Date()
+	return time.Date(year, month, day+1, c.RolloverHour(), 0, 0, 0, time.Local)
+}
+
+// SameDay reports whether a and b fall on the same learning day.
+func (c *Calendar) SameDay(a, b time.Time) bool {
+	return c.DayStart(a).Equal(c.DayStart(b))
+}
+
+// Today returns the start of the current learning day.
+func (c *Calendar) Today() time.Time {
+	return c.DayStart(c.Now())
+}
+
+// Tomorrow returns the start of the next learning day, when cards scheduled
+// for tomorrow become due.
+func (c *Calendar) Tomorrow() time.Time {
+	return c.NextDayStart(c.Now())
+}
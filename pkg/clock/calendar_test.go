@@ -0,0 +1,60 @@
+package clock_test
+
+import (
+	"memoflash/pkg/clock"
+	"testing"
+	"time"
+)
+
+func TestCalendar(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	saved := time.Local
+	time.Local = zone
+	defer func() { time.Local = saved }()
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2025, time.March, day, hour, min, 0, 0, zone)
+	}
+
+	tests := []struct {
+		name      string
+		rollover  int
+		now       time.Time
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{name: "midnight", rollover: 0, now: at(14, 15, 0), wantStart: at(14, 0, 0), wantNext: at(15, 0, 0)},
+		{name: "after the rollover", rollover: 4, now: at(14, 15, 0), wantStart: at(14, 4, 0), wantNext: at(15, 4, 0)},
+		{name: "at the rollover", rollover: 4, now: at(14, 4, 0), wantStart: at(14, 4, 0), wantNext: at(15, 4, 0)},
+		{name: "before the rollover", rollover: 4, now: at(14, 3, 59), wantStart: at(13, 4, 0), wantNext: at(14, 4, 0)},
+		{name: "end of month", rollover: 4, now: time.Date(2025, time.April, 1, 2, 0, 0, 0, zone), wantStart: at(31, 4, 0), wantNext: time.Date(2025, time.April, 1, 4, 0, 0, 0, zone)},
+		{name: "UTC time", rollover: 4, now: time.Date(2025, time.March, 15, 3, 0, 0, 0, time.UTC), wantStart: at(14, 4, 0), wantNext: at(15, 4, 0)},
+		{name: "hour clamped", rollover: 30, now: at(14, 22, 0), wantStart: at(13, 23, 0), wantNext: at(14, 23, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := clock.NewCalendar(clock.NewFake(tt.now), tt.rollover)
+			if got := calendar.Today(); !got.Equal(tt.wantStart) {
+				t.Errorf("Today() = %v, want %v", got, tt.wantStart)
+			}
+			if got := calendar.Tomorrow(); !got.Equal(tt.wantNext) {
+				t.Errorf("Tomorrow() = %v, want %v", got, tt.wantNext)
+			}
+			if !calendar.SameDay(tt.now, tt.wantStart) || calendar.SameDay(tt.now, tt.wantNext) {
+				t.Errorf("SameDay() disagrees with the day starting at %v", tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestCalendarFollowsRolloverChanges(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, time.March, 14, 5, 0, 0, 0, time.Local))
+	calendar := clock.NewCalendar(fake, 4)
+	before := calendar.Today()
+	calendar.SetRolloverHour(6)
+	if calendar.RolloverHour() != 6 {
+		t.Fatalf("RolloverHour() = %d, want 6", calendar.RolloverHour())
+	}
+	if got := calendar.Today(); !got.Before(before) {
+		t.Errorf("Today() = %v after moving the rollover past now, want the previous day", got)
+	}
+}
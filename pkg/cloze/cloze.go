@@ -0,0 +1,98 @@
+package cloze
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Blank is shown in place of the active deletion when it has no hint.
+const Blank = "[...]"
+
+// deletionPattern matches {{c1::answer}} and {{c1::answer::hint}}.
+var deletionPattern = regexp.MustCompile(`(?s)\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+// Deletion is a single cloze deletion found in a text.
+type Deletion struct {
+	Number int
+	Answer string
+	Hint   string
+}
+
+// Parse returns every deletion in text in order of appearance. Deletions
+// numbered below 1 are not deletions and are left out.
+func Parse(text string) []Deletion {
+	var deletions []Deletion
+	for _, match := range deletionPattern.FindAllStringSubmatch(text, -1) {
+		number, err := strconv.Atoi(match[1])
+		if err != nil || number < 1 {
+			continue
+		}
+		deletions = append(deletions, Deletion{Number: number, Answer: match[2], Hint: match[3]})
+	}
+	return deletions
+}
+
+// Numbers returns the distinct cloze numbers used in text, sorted ascending.
+// Each number becomes one card.
+func Numbers(text string) []int {
+	var numbers []int
+	for _, deletion := range Parse(text) {
+		if !slices.Contains(numbers, deletion.Number) {
+			numbers = append(numbers, deletion.Number)
+		}
+	}
+	slices.Sort(numbers)
+	return numbers
+}
+
+// Front renders the question side for cloze number: the active deletions
+// become a blank, or the hint in brackets, and every other deletion shows
+// its answer.
+func Front(text string, number int) string {
+	return render(text, func(deletion Deletion) string {
+		if deletion.Number != number {
+			return deletion.Answer
+		}
+		if deletion.Hint != "" {
+			return "[" + deletion.Hint + "]"
+		}
+		return Blank
+	})
+}
+
+// Back renders the answer side for cloze number, highlighting the active
+// deletions.
+func Back(text string, number int) string {
+	return render(text, func(deletion Deletion) string {
+		if deletion.Number != number {
+			return deletion.Answer
+		}
+		return "<b>" + deletion.Answer + "</b>"
+	})
+}
+
+// Wrap surrounds selection with a deletion for number.
+func Wrap(selection string, number int) string {
+	return fmt.Sprintf("{{c%d::%s}}", number, selection)
+}
+
+// NextNumber returns the number a new deletion in text should use.
+func NextNumber(text string) int {
+	numbers := Numbers(text)
+	if len(numbers) == 0 {
+		return 1
+	}
+	return numbers[len(numbers)-1] + 1
+}
+
+func render(text string, replace func(Deletion) string) string {
+	return deletionPattern.ReplaceAllStringFunc(text, func(match string) string {
+		deletions := Parse(match)
+		if len(deletions) == 0 {
+			return match
+		}
+		return replace(deletions[0])
+	})
+}
@@ -0,0 +1,97 @@
+package cloze_test
+
+import (
+	"memoflash/pkg/cloze"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []cloze.Deletion
+	}{
+		{"no deletions here", nil},
+		{"{{c1::France}}", []cloze.Deletion{{Number: 1, Answer: "France"}}},
+		{"{{c1::Paris::city}}", []cloze.Deletion{{Number: 1, Answer: "Paris", Hint: "city"}}},
+		{"{{c2::a}} and {{c10::b::hint}}", []cloze.Deletion{{Number: 2, Answer: "a"}, {Number: 10, Answer: "b", Hint: "hint"}}},
+		{"time is 10:30 {{c1::12:00}}", []cloze.Deletion{{Number: 1, Answer: "12:00"}}},
+		{"{{c1::multi\nline}}", []cloze.Deletion{{Number: 1, Answer: "multi\nline"}}},
+		{"{{cx::invalid}} {{c1:missing colon}}", nil},
+		{"{{c0::zero}} {{c00::zeros}} {{c1::one}}", []cloze.Deletion{{Number: 1, Answer: "one"}}},
+	}
+
+	for _, tt := range tests {
+		if got := cloze.Parse(tt.input); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int
+	}{
+		{"plain text", nil},
+		{"The capital of {{c1::France}} is {{c2::Paris}}", []int{1, 2}},
+		{"{{c3::a}} {{c1::b}} {{c3::c}}", []int{1, 3}},
+		{"{{c0::a}}", nil},
+	}
+
+	for _, tt := range tests {
+		if got := cloze.Numbers(tt.input); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Numbers(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestFrontAndBack(t *testing.T) {
+	text := "The capital of {{c1::France}} is {{c2::Paris::city}}"
+
+	tests := []struct {
+		number int
+		front  string
+		back   string
+	}{
+		{1, "The capital of [...] is Paris", "The capital of <b>France</b> is Paris"},
+		{2, "The capital of France is [city]", "The capital of France is <b>Paris</b>"},
+		{3, "The capital of France is Paris", "The capital of France is Paris"},
+	}
+
+	for _, tt := range tests {
+		if got := cloze.Front(text, tt.number); got != tt.front {
+			t.Errorf("Front(%d) = %q, want %q", tt.number, got, tt.front)
+		}
+		if got := cloze.Back(text, tt.number); got != tt.back {
+			t.Errorf("Back(%d) = %q, want %q", tt.number, got, tt.back)
+		}
+	}
+}
+
+func TestSharedNumberBlanksEveryDeletion(t *testing.T) {
+	text := "{{c1::H}} and {{c1::O}} make {{c2::water}}"
+	if got, want := cloze.Front(text, 1), "[...] and [...] make water"; got != want {
+		t.Errorf("Front = %q, want %q", got, want)
+	}
+}
+
+func TestWrapAndNextNumber(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 1},
+		{"{{c1::a}}", 2},
+		{"{{c1::a}} {{c4::b}}", 5},
+	}
+
+	for _, tt := range tests {
+		if got := cloze.NextNumber(tt.text); got != tt.expected {
+			t.Errorf("NextNumber(%q) = %d, want %d", tt.text, got, tt.expected)
+		}
+	}
+	if got, want := cloze.Wrap("Paris", 2), "{{c2::Paris}}"; got != want {
+		t.Errorf("Wrap = %q, want %q", got, want)
+	}
+}
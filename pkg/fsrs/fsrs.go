@@ -5,6 +5,7 @@ import (
 	"math"
 	"memoflash/internal/models"
 	"memoflash/internal/values"
+	"memoflash/pkg/clock"
 	"time"
 )
 
@@ -32,12 +33,64 @@ const (
 	RequestedRetention = 0.9
 )
 
+// DefaultWeights are the W0 to W10 weights used when a preset doesn't set its
+// own.
+var DefaultWeights = []float64{W0, W1, W2, W3, W4, W5, W6, W7, W8, W9, W10}
+
+// Parameters tune the scheduler. Decks take them from their option preset.
+type Parameters struct {
+	// Weights replaces W0 to W10, in that order.
+	Weights []float64
+	// DesiredRetention is the recall probability a card is scheduled for.
+	DesiredRetention float64
+	// MaximumInterval caps the interval, in days.
+	MaximumInterval int
+	// LearningSteps are the short intervals a card goes through, instead of
+	// whole days, after it is failed or rated Hard while new. Good moves it
+	// to the next step and graduates it after the last one.
+	LearningSteps []time.Duration
+	// Clock tells the time of the review; nil means the system clock.
+	Clock clock.Clock
+}
+
+// now returns the current time of the parameters' clock.
+func (p Parameters) now() time.Time {
+	if p.Clock == nil {
+		return clock.System.Now()
+	}
+	return p.Clock.Now()
+}
+
+func DefaultParameters() Parameters {
+	return Parameters{
+		Weights:          DefaultWeights,
+		DesiredRetention: RequestedRetention,
+		MaximumInterval:  MaxStability,
+	}
+}
+
+// w returns weight i, falling back to the default when the parameters carry
+// too few weights.
+func (p Parameters) w(i int) float64 {
+	if i < len(p.Weights) {
+		return p.Weights[i]
+	}
+	return DefaultWeights[i]
+}
+
 type FSRSEngine struct {
 	card *models.Card
 }
 
+// Review schedules card with the default parameters.
 func Review(difficulty values.Difficulty, card *models.Card) {
-	daysSinceLastReview := time.Since(card.LastStudied).Hours() / 24
+	ReviewWith(DefaultParameters(), difficulty, card)
+}
+
+// ReviewWith schedules card for difficulty using params.
+func ReviewWith(params Parameters, difficulty values.Difficulty, card *models.Card) {
+	now := params.now()
+	daysSinceLastReview := now.Sub(card.LastStudied).Hours() / 24
 
 	// Minimum time between reviews
 	if daysSinceLastReview < 0.05 {
@@ -48,13 +101,13 @@ func Review(difficulty values.Difficulty, card *models.Card) {
 
 	switch difficulty {
 	case values.Again:
-		handleAgainReview(card, daysSinceLastReview, isNewCard)
+		handleAgainReview(params, card, daysSinceLastReview, isNewCard)
 	case values.Hard:
-		handleHardReview(card, daysSinceLastReview, isNewCard)
+		handleHardReview(params, card, daysSinceLastReview, isNewCard)
 	case values.Good:
-		handleGoodReview(card, daysSinceLastReview, isNewCard)
+		handleGoodReview(params, card, daysSinceLastReview, isNewCard)
 	case values.Easy:
-		handleEasyReview(card, daysSinceLastReview, isNewCard)
+		handleEasyReview(params, card, daysSinceLastReview, isNewCard)
 	default:
 		log.Println("Invalid difficulty")
 		return
@@ -65,61 +118,95 @@ func Review(difficulty values.Difficulty, card *models.Card) {
 	card.Difficulty = math.Max(MinDifficulty, math.Min(MaxDifficulty, card.Difficulty))
 
 	// Calculate next review interval
-	interval := calculateInterval(card.Stability)
-	card.Interval = time.Now().AddDate(0, 0, int(interval))
-	card.LastStudied = time.Now()
+	if step, ok := learningStep(params, difficulty, card, isNewCard); ok {
+		card.Interval = now.Add(step)
+	} else {
+		interval := calculateInterval(params, card.Stability)
+		card.Interval = now.AddDate(0, 0, int(interval))
+	}
+	card.LastStudied = now
 }
 
-func handleAgainReview(card *models.Card, daysSince float64, isNewCard bool) {
+// learningStep moves card to its next learning step and returns the interval
+// until it is due again. A new card rated Hard or Good enters the first step,
+// Again restarts the steps, Hard repeats the current one and Good moves on to
+// the next. It reports false once the card graduates, after Good on the last
+// step or after Easy, or when the parameters define no learning steps.
+func learningStep(params Parameters, difficulty values.Difficulty, card *models.Card, isNewCard bool) (time.Duration, bool) {
+	steps := params.LearningSteps
+	switch {
+	case len(steps) == 0:
+		card.Step = 0
+		return 0, false
+	case difficulty == values.Again:
+		card.Step = 1
+	case difficulty == values.Hard && card.Step > 0:
+	case (difficulty == values.Hard || difficulty == values.Good) && isNewCard:
+		card.Step = 1
+	case difficulty == values.Good && card.Step > 0 && card.Step < len(steps):
+		card.Step++
+	default:
+		card.Step = 0
+		return 0, false
+	}
+	// The preset may have lost steps since the card entered them.
+	return steps[min(card.Step, len(steps))-1], true
+}
+
+func handleAgainReview(params Parameters, card *models.Card, daysSince float64, isNewCard bool) {
 	if isNewCard {
-		card.Stability = W0
+		card.Stability = params.w(0)
 		card.Difficulty = MinDifficulty
 	} else {
+		// Failing a card still in learning is not a lapse.
+		if card.Step == 0 {
+			card.Lapses++
+		}
 		// For failed reviews, use actual retrievability to adjust stability decrease
 		retrievability := calculateRetrievability(daysSince, card.Stability)
 		// Worse retrievability (forgot sooner) = bigger stability decrease
-		stabilityDecrease := W10 * (1.0 + (1.0 - retrievability)) * math.Pow(card.Difficulty/MaxDifficulty, W9)
+		stabilityDecrease := params.w(10) * (1.0 + (1.0 - retrievability)) * math.Pow(card.Difficulty/MaxDifficulty, params.w(9))
 		card.Stability = card.Stability * stabilityDecrease
-		card.Difficulty = math.Min(card.Difficulty+W8, MaxDifficulty)
+		card.Difficulty = math.Min(card.Difficulty+params.w(8), MaxDifficulty)
 	}
 }
 
-func handleHardReview(card *models.Card, daysSince float64, isNewCard bool) {
+func handleHardReview(params Parameters, card *models.Card, daysSince float64, isNewCard bool) {
 	if isNewCard {
-		card.Stability = W1
+		card.Stability = params.w(1)
 		card.Difficulty = MinDifficulty + 1.0
 	} else {
 		retrievability := calculateRetrievability(daysSince, card.Stability)
-		stabilityIncrease := calculateStabilityIncrease(retrievability, card.Difficulty)
+		stabilityIncrease := calculateStabilityIncrease(params, retrievability, card.Difficulty)
 
-		card.Stability = card.Stability * stabilityIncrease * W6          // Hard penalty
-		card.Difficulty = math.Max(card.Difficulty-W7*0.5, MinDifficulty) // Small difficulty decrease
+		card.Stability = card.Stability * stabilityIncrease * params.w(6)          // Hard penalty
+		card.Difficulty = math.Max(card.Difficulty-params.w(7)*0.5, MinDifficulty) // Small difficulty decrease
 	}
 }
 
-func handleGoodReview(card *models.Card, daysSince float64, isNewCard bool) {
+func handleGoodReview(params Parameters, card *models.Card, daysSince float64, isNewCard bool) {
 	if isNewCard {
-		card.Stability = W2
+		card.Stability = params.w(2)
 		card.Difficulty = MinDifficulty + 0.5
 	} else {
 		retrievability := calculateRetrievability(daysSince, card.Stability)
-		stabilityIncrease := calculateStabilityIncrease(retrievability, card.Difficulty)
+		stabilityIncrease := calculateStabilityIncrease(params, retrievability, card.Difficulty)
 
-		card.Stability = card.Stability * stabilityIncrease * W4
-		card.Difficulty = math.Max(card.Difficulty-W7, MinDifficulty)
+		card.Stability = card.Stability * stabilityIncrease * params.w(4)
+		card.Difficulty = math.Max(card.Difficulty-params.w(7), MinDifficulty)
 	}
 }
 
-func handleEasyReview(card *models.Card, daysSince float64, isNewCard bool) {
+func handleEasyReview(params Parameters, card *models.Card, daysSince float64, isNewCard bool) {
 	if isNewCard {
-		card.Stability = W3
+		card.Stability = params.w(3)
 		card.Difficulty = MinDifficulty
 	} else {
 		retrievability := calculateRetrievability(daysSince, card.Stability)
-		stabilityIncrease := calculateStabilityIncrease(retrievability, card.Difficulty)
+		stabilityIncrease := calculateStabilityIncrease(params, retrievability, card.Difficulty)
 
-		card.Stability = card.Stability * stabilityIncrease * W4 * W5     // Easy bonus
-		card.Difficulty = math.Max(card.Difficulty-W7*1.5, MinDifficulty) // Larger difficulty decrease
+		card.Stability = card.Stability * stabilityIncrease * params.w(4) * params.w(5) // Easy bonus
+		card.Difficulty = math.Max(card.Difficulty-params.w(7)*1.5, MinDifficulty)      // Larger difficulty decrease
 	}
 }
 
@@ -127,16 +214,22 @@ func calculateRetrievability(daysSince, stability float64) float64 {
 	return math.Exp(math.Log(RequestedRetention) * daysSince / stability)
 }
 
-func calculateStabilityIncrease(retrievability, difficulty float64) float64 {
+func calculateStabilityIncrease(params Parameters, retrievability, difficulty float64) float64 {
 	difficultyFactor := (MaxDifficulty - difficulty) / MaxDifficulty
-	retrievabilityFactor := 1.0 + W9*(1.0-retrievability)
+	retrievabilityFactor := 1.0 + params.w(9)*(1.0-retrievability)
 
 	return 1.0 + difficultyFactor*retrievabilityFactor
 }
 
-func calculateInterval(stability float64) float64 {
-	interval := stability * math.Log(RequestedRetention) / math.Log(RequestedRetention)
-	interval = stability // At stability days, retrievability ≈ 0.9
-
+// calculateInterval returns the days until recall drops to the desired
+// retention. At stability days, retrievability ≈ 0.9.
+func calculateInterval(params Parameters, stability float64) float64 {
+	interval := stability
+	if params.DesiredRetention > 0 && params.DesiredRetention < 1 {
+		interval = stability * math.Log(params.DesiredRetention) / math.Log(RequestedRetention)
+	}
+	if params.MaximumInterval > 0 {
+		interval = math.Min(interval, float64(params.MaximumInterval))
+	}
 	return math.Max(1.0, interval)
 }
@@ -0,0 +1,123 @@
+package fsrs_test
+
+import (
+	"memoflash/internal/models"
+	"memoflash/internal/values"
+	"memoflash/pkg/clock"
+	"memoflash/pkg/fsrs"
+	"testing"
+	"time"
+)
+
+func TestLearningSteps(t *testing.T) {
+	now := time.Date(2025, time.March, 14, 15, 0, 0, 0, time.UTC)
+	steps := []time.Duration{time.Minute, 10 * time.Minute, time.Hour}
+
+	type answer struct {
+		rating   values.Difficulty
+		wantStep int
+		// wantWait is the time until the card is due again; zero means it
+		// graduated to whole days.
+		wantWait   time.Duration
+		wantLapses int
+	}
+	tests := []struct {
+		name    string
+		review  bool
+		answers []answer
+	}{
+		{
+			name: "new card through every step",
+			answers: []answer{
+				{values.Hard, 1, time.Minute, 0},
+				{values.Good, 2, 10 * time.Minute, 0},
+				{values.Good, 3, time.Hour, 0},
+				{values.Good, 0, 0, 0},
+			},
+		},
+		{
+			name: "again restarts, hard repeats",
+			answers: []answer{
+				{values.Again, 1, time.Minute, 0},
+				{values.Good, 2, 10 * time.Minute, 0},
+				{values.Hard, 2, 10 * time.Minute, 0},
+				{values.Again, 1, time.Minute, 0},
+				{values.Good, 2, 10 * time.Minute, 0},
+			},
+		},
+		{
+			name: "easy graduates at once",
+			answers: []answer{
+				{values.Again, 1, time.Minute, 0},
+				{values.Easy, 0, 0, 0},
+			},
+		},
+		{
+			name:   "review card relearns after a lapse",
+			review: true,
+			answers: []answer{
+				{values.Again, 1, time.Minute, 1},
+				{values.Again, 1, time.Minute, 1},
+				{values.Good, 2, 10 * time.Minute, 1},
+				{values.Good, 3, time.Hour, 1},
+				{values.Good, 0, 0, 1},
+			},
+		},
+		{
+			name: "new card rated good enters the steps",
+			answers: []answer{
+				{values.Good, 1, time.Minute, 0},
+				{values.Good, 2, 10 * time.Minute, 0},
+				{values.Good, 3, time.Hour, 0},
+				{values.Good, 0, 0, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clock.NewFake(now)
+			params := fsrs.DefaultParameters()
+			params.LearningSteps = steps
+			params.Clock = fake
+			card := &models.Card{Stability: 5, Difficulty: 5}
+			if tt.review {
+				card.LastStudied = now.AddDate(0, 0, -5)
+			}
+			for i, a := range tt.answers {
+				fsrs.ReviewWith(params, a.rating, card)
+				if card.Step != a.wantStep {
+					t.Errorf("answer %d: Step = %d, want %d", i, card.Step, a.wantStep)
+				}
+				wait := card.Interval.Sub(fake.Now())
+				switch {
+				case a.wantWait == 0 && wait < 24*time.Hour:
+					t.Errorf("answer %d: due in %v, want whole days", i, wait)
+				case a.wantWait != 0 && wait != a.wantWait:
+					t.Errorf("answer %d: due in %v, want %v", i, wait, a.wantWait)
+				}
+				if card.Lapses != a.wantLapses {
+					t.Errorf("answer %d: Lapses = %d, want %d", i, card.Lapses, a.wantLapses)
+				}
+				fake.Set(card.Interval)
+			}
+		})
+	}
+}
+
+func TestLearningStepsRemoved(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, time.March, 14, 15, 0, 0, 0, time.UTC))
+	params := fsrs.DefaultParameters()
+	params.LearningSteps = []time.Duration{time.Minute}
+	params.Clock = fake
+	// The card entered a third step of a preset that has since lost two.
+	card := &models.Card{LastStudied: fake.Now().Add(-time.Hour), Stability: 1, Difficulty: 5, Step: 3}
+
+	fsrs.ReviewWith(params, values.Hard, card)
+	if card.Step != 3 || card.Interval.Sub(fake.Now()) != time.Minute {
+		t.Errorf("Hard: Step = %d, due in %v, want the last step", card.Step, card.Interval.Sub(fake.Now()))
+	}
+	fsrs.ReviewWith(params, values.Good, card)
+	if card.Step != 0 {
+		t.Errorf("Good: Step = %d, want the card graduated", card.Step)
+	}
+}
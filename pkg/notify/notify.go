@@ -0,0 +1,76 @@
+// Package notify raises desktop notifications.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnavailable is returned when the desktop offers no way to show
+// notifications.
+var ErrUnavailable = errors.New("desktop notifications are unavailable")
+
+// Notification is a message shown to the user outside the app's window.
+type Notification struct {
+	Title string
+	Body  string
+}
+
+// Notifier shows notifications.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Command is a Notifier running a command line tool that talks to the
+// freedesktop notification service over D-Bus: notify-send, or gdbus when
+// notify-send is not installed.
+type Command struct {
+	// AppName is shown as the sender of the notifications.
+	AppName string
+	// Icon is the name or path of the icon of the notifications.
+	Icon string
+
+	path string
+}
+
+// New returns a Command notifier for the tool found on the PATH, or
+// ErrUnavailable when there is none.
+func New(appName string) (*Command, error) {
+	for _, name := range []string{"notify-send", "gdbus"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return &Command{AppName: appName, path: path}, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
+
+func (c *Command) Notify(ctx context.Context, n Notification) error {
+	cmd := exec.CommandContext(ctx, c.path, c.args(n)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// args returns the arguments of the tool for n.
+func (c *Command) args(n Notification) []string {
+	if !strings.HasSuffix(c.path, "gdbus") {
+		args := []string{"--app-name", c.AppName}
+		if c.Icon != "" {
+			args = append(args, "--icon", c.Icon)
+		}
+		return append(args, "--", n.Title, n.Body)
+	}
+	// org.freedesktop.Notifications.Notify(app_name, replaces_id, app_icon,
+	// summary, body, actions, hints, expire_timeout)
+	return []string{
+		"call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		c.AppName, "0", c.Icon, n.Title, n.Body, "[]", "{}", "-1",
+	}
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	n := Notification{Title: "Time to study", Body: "2 cards are due"}
+	tests := []struct {
+		name    string
+		command Command
+		want    []string
+	}{
+		{
+			name:    "notify-send",
+			command: Command{AppName: "MemoFlash", path: "/usr/bin/notify-send"},
+			want:    []string{"--app-name", "MemoFlash", "--", "Time to study", "2 cards are due"},
+		},
+		{
+			name:    "notify-send with icon",
+			command: Command{AppName: "MemoFlash", Icon: "memoflash", path: "/usr/bin/notify-send"},
+			want:    []string{"--app-name", "MemoFlash", "--icon", "memoflash", "--", "Time to study", "2 cards are due"},
+		},
+		{
+			name:    "gdbus",
+			command: Command{AppName: "MemoFlash", path: "/usr/bin/gdbus"},
+			want: []string{
+				"call", "--session",
+				"--dest", "org.freedesktop.Notifications",
+				"--object-path", "/org/freedesktop/Notifications",
+				"--method", "org.freedesktop.Notifications.Notify",
+				"MemoFlash", "0", "", "Time to study", "2 cards are due", "[]", "{}", "-1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.command.args(n); !slices.Equal(got, tt.want) {
+				t.Errorf("args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package typeanswer
+
+import (
+	"html"
+	"memoflash/internal/values"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// OpKind says how a piece of a Diff relates the typed answer to the
+// expected one.
+type OpKind int
+
+const (
+	// Equal text appears in both answers.
+	Equal OpKind = iota
+	// Missing text is in the expected answer but was not typed.
+	Missing
+	// Extra text was typed but is not in the expected answer.
+	Extra
+)
+
+// Op is a run of characters of the same kind.
+type Op struct {
+	Kind OpKind
+	Text string
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// PlainText turns a rendered card side into the text a learner would type:
+// tags become spaces and entities are decoded.
+func PlainText(side string) string {
+	return html.UnescapeString(tagPattern.ReplaceAllString(side, " "))
+}
+
+// Normalize prepares an answer for comparison: it lowercases the text,
+// strips diacritics and collapses every run of whitespace into one space.
+func Normalize(answer string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(strings.TrimSpace(answer)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Diff compares the normalized answers character by character and returns
+// the runs that are equal, missing from given or extra in given.
+func Diff(expected, given string) []Op {
+	a := []rune(Normalize(expected))
+	b := []rune(Normalize(given))
+	lengths := lcsTable(a, b)
+
+	var ops []Op
+	add := func(kind OpKind, r rune) {
+		if n := len(ops); n > 0 && ops[n-1].Kind == kind {
+			ops[n-1].Text += string(r)
+			return
+		}
+		ops = append(ops, Op{Kind: kind, Text: string(r)})
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			add(Equal, a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lengths[i][j+1] >= lengths[i+1][j]):
+			add(Extra, b[j])
+			j++
+		default:
+			add(Missing, a[i])
+			i++
+		}
+	}
+	return ops
+}
+
+// Similarity returns how close given is to expected once both are
+// normalized, from 0 for nothing in common to 1 for a match.
+func Similarity(expected, given string) float64 {
+	a := []rune(Normalize(expected))
+	b := []rune(Normalize(given))
+	if len(a)+len(b) == 0 {
+		return 1
+	}
+	return 2 * float64(lcsTable(a, b)[0][0]) / float64(len(a)+len(b))
+}
+
+// Match reports whether the answers are equal once normalized.
+func Match(expected, given string) bool {
+	return Normalize(expected) == Normalize(given)
+}
+
+// SuggestRating maps how close the typed answer was onto a rating: a match
+// is Good, a near miss Hard and anything else Again.
+func SuggestRating(expected, given string) values.Difficulty {
+	switch similarity := Similarity(expected, given); {
+	case similarity == 1:
+		return values.Good
+	case similarity >= 0.8:
+		return values.Hard
+	default:
+		return values.Again
+	}
+}
+
+// lcsTable returns the table of longest common subsequence lengths, where
+// cell [i][j] holds the length for a[i:] and b[j:].
+func lcsTable(a, b []rune) [][]int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	return lengths
+}
@@ -0,0 +1,107 @@
+package typeanswer_test
+
+import (
+	"memoflash/internal/values"
+	"memoflash/pkg/typeanswer"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"Paris", "paris"},
+		{"  São   Paulo \n", "sao paulo"},
+		{"Crème Brûlée", "creme brulee"},
+		{"naïve\tcafé", "naive cafe"},
+		{"ÅNGSTRÖM", "angstrom"},
+	}
+
+	for _, tt := range tests {
+		if got := typeanswer.Normalize(tt.input); got != tt.expected {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	if got, want := typeanswer.Normalize(typeanswer.PlainText("<b>Tom</b><br>&amp; Jerry")), "tom & jerry"; got != want {
+		t.Errorf("PlainText = %q, want %q", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		expected string
+		given    string
+		ops      []typeanswer.Op
+	}{
+		{"paris", "Paris", []typeanswer.Op{{Kind: typeanswer.Equal, Text: "paris"}}},
+		{"paris", "", []typeanswer.Op{{Kind: typeanswer.Missing, Text: "paris"}}},
+		{"", "rome", []typeanswer.Op{{Kind: typeanswer.Extra, Text: "rome"}}},
+		{"colour", "color", []typeanswer.Op{
+			{Kind: typeanswer.Equal, Text: "colo"},
+			{Kind: typeanswer.Missing, Text: "u"},
+			{Kind: typeanswer.Equal, Text: "r"},
+		}},
+		{"cat", "cart", []typeanswer.Op{
+			{Kind: typeanswer.Equal, Text: "ca"},
+			{Kind: typeanswer.Extra, Text: "r"},
+			{Kind: typeanswer.Equal, Text: "t"},
+		}},
+		{"cat", "cut", []typeanswer.Op{
+			{Kind: typeanswer.Equal, Text: "c"},
+			{Kind: typeanswer.Extra, Text: "u"},
+			{Kind: typeanswer.Missing, Text: "a"},
+			{Kind: typeanswer.Equal, Text: "t"},
+		}},
+	}
+
+	for _, tt := range tests {
+		if got := typeanswer.Diff(tt.expected, tt.given); !reflect.DeepEqual(got, tt.ops) {
+			t.Errorf("Diff(%q, %q) = %v, want %v", tt.expected, tt.given, got, tt.ops)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		expected string
+		given    string
+		want     float64
+	}{
+		{"", "", 1},
+		{"Café", "cafe", 1},
+		{"abcd", "", 0},
+		{"abcd", "abce", 0.75},
+		{"abc", "xyz", 0},
+	}
+
+	for _, tt := range tests {
+		if got := typeanswer.Similarity(tt.expected, tt.given); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.expected, tt.given, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestRating(t *testing.T) {
+	tests := []struct {
+		expected string
+		given    string
+		rating   values.Difficulty
+	}{
+		{"Mitochondria", "mitochondria", values.Good},
+		{"Mitochondria", "mitochondira", values.Hard},
+		{"Mitochondria", "ribosome", values.Again},
+		{"Mitochondria", "", values.Again},
+	}
+
+	for _, tt := range tests {
+		if got := typeanswer.SuggestRating(tt.expected, tt.given); got != tt.rating {
+			t.Errorf("SuggestRating(%q, %q) = %v, want %v", tt.expected, tt.given, got, tt.rating)
+		}
+	}
+}